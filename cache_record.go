@@ -13,17 +13,33 @@ import (
 
 // CacheRecord defines struct of resource create cache record
 type CacheRecord struct {
+	// Key identifies the resource in the stack, see Stack.resourceKey
+	Key          string `json:",omitempty"`
 	Name         string
 	ResourceType string
+	Action       string `json:",omitempty"`
 	InTemplate   bool
+	Depth        int `json:",omitempty"` // nesting depth of template, 0 if not in template
 	ValueType    string
 	Value        json.RawMessage
+	// AdoptedItems are identifies of existing items in value of a list resource, which are
+	// kept when the resource is destroyed
+	AdoptedItems []string `json:",omitempty"`
 }
 
 func (r *CacheRecord) String() string {
 	return fmt.Sprintf("CacheRecord(ResourceName:%s, ResourceType:%s)", r.Name, r.ResourceType)
 }
 
+// GetDepth returns nesting depth of template of the record, records written before nested
+// templates are supported have InTemplate only
+func (r *CacheRecord) GetDepth() int {
+	if r.Depth == 0 && r.InTemplate {
+		return 1
+	}
+	return r.Depth
+}
+
 func (r *CacheRecord) getValueType(typ string) reflect.Type {
 	switch {
 	case typ == "string":
@@ -50,6 +66,31 @@ func (r *CacheRecord) getContainerType(typ string) reflect.Type {
 	return r.getValueType(typ)
 }
 
+// GetCreatedExpr returns resource's expr in cache record without adopted items, which is
+// what the stack created
+func (r *CacheRecord) GetCreatedExpr() (interface{}, error) {
+	val, err := r.GetExpr()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	listVal := reflect.ValueOf(val)
+	if len(r.AdoptedItems) == 0 || listVal.Kind() != reflect.Slice {
+		return val, nil
+	}
+
+	adopted := make(map[string]bool, len(r.AdoptedItems))
+	for _, item := range r.AdoptedItems {
+		adopted[item] = true
+	}
+	created := reflect.MakeSlice(listVal.Type(), 0, listVal.Len())
+	for i := 0; i < listVal.Len(); i++ {
+		if !adopted[fmt.Sprint(listVal.Index(i).Interface())] {
+			created = reflect.Append(created, listVal.Index(i))
+		}
+	}
+	return created.Interface(), nil
+}
+
 // GetExpr returns resource's expr in cache record
 func (r *CacheRecord) GetExpr() (interface{}, error) {
 	if r.ResourceType == utils.ResourceTemplate {
@@ -146,7 +187,7 @@ func encodeTemplateCacheType(value interface{}, resourceName string) (string, er
 }
 
 // GetCacheRecord returns a cache record object
-func GetCacheRecord(resourceName, resourceType string, value interface{}, inTmpl bool) (
+func GetCacheRecord(resourceName, resourceType string, value interface{}, depth int) (
 	*CacheRecord, error) {
 
 	valueBytes, err := json.Marshal(value)
@@ -164,7 +205,8 @@ func GetCacheRecord(resourceName, resourceType string, value interface{}, inTmpl
 		Name:         resourceName,
 		ResourceType: resourceType,
 		ValueType:    valueType,
-		InTemplate:   inTmpl,
+		InTemplate:   depth > 0,
+		Depth:        depth,
 		Value:        json.RawMessage(valueBytes),
 	}, nil
 }
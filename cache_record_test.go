@@ -34,6 +34,14 @@ func (s *cacheRecordSuite) TestGetRecordExpr() {
 	testFunc("[]float64", "[1]", []float64{1})
 	testFunc("string", "\"test\"", "test")
 	testFunc("[]string", "[\"test1\", \"test2\"]", []string{"test1", "test2"})
+	testFunc("bool", "true", true)
+	testFunc("[]bool", "[true, false]", []bool{true, false})
+
+	record, err := GetCacheRecord("test", "test", []bool{false, true}, 0)
+	s.NoError(err)
+	val, err := record.GetExpr()
+	s.NoError(err)
+	s.Equal([]bool{false, true}, val)
 }
 
 func (s *cacheRecordSuite) TestGetExprWithUnsupportedValueType() {
@@ -59,8 +67,10 @@ func (s *cacheRecordSuite) TestGetTemplateResourceCache() {
 			"e": []string{"qwe", "zxc", "ad"},
 		},
 	}
-	cacheRecord, err := GetCacheRecord("test", utils.ResourceTemplate, val, true)
+	cacheRecord, err := GetCacheRecord("test", utils.ResourceTemplate, val, 2)
 	s.NoError(err)
+	s.True(cacheRecord.InTemplate)
+	s.Equal(2, cacheRecord.GetDepth())
 
 	valActual, err := cacheRecord.GetTemplateExpr()
 	s.NoError(err)
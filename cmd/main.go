@@ -1,16 +1,52 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/juju/errors"
 
 	formation "xsky.com/sds-formation"
 	"xsky.com/sds-formation/config"
+	"xsky.com/sds-formation/utils"
 )
 
+// commands of formation, create is used if no command specified
+const (
+	commandCreate   = "create"
+	commandPlan     = "plan"
+	commandDestroy  = "destroy"
+	commandValidate = "validate"
+	commandSchema   = "schema"
+)
+
+// paramsValue is value of flags setting parameters in Name=value format
+type paramsValue map[string]string
+
+func (params paramsValue) String() string {
+	items := make([]string, 0, len(params))
+	for name, value := range params {
+		items = append(items, name+"="+value)
+	}
+	return strings.Join(items, ",")
+}
+
+func (params paramsValue) Set(value string) error {
+	items := strings.SplitN(value, "=", 2)
+	if len(items) != 2 || items[0] == "" {
+		return errors.Errorf("invalid parameter %q, Name=value is expected", value)
+	}
+	params[items[0]] = items[1]
+	return nil
+}
+
 var (
 	templateFile string
 	version      bool
@@ -22,29 +58,141 @@ func init() {
 		"Report resource created successfully, but not really create them")
 	flag.StringVar(&config.CachePath, "cache-path", "formation_cache", "Specify cache record path")
 	flag.BoolVar(&config.NoContinue, "no-continue", false, "Do not continue from last run")
+	flag.StringVar(&config.StateFile, "state-file", "",
+		"Specify stack state file, <cache-path>/<stack hash>.state.json is used by default")
+	flag.IntVar(&config.Workers, "workers", 4,
+		"Specify max number of independent resources created at the same time")
+	flag.Var(paramsValue(config.Params), "p",
+		"Set value of parameter in Name=value format, could be specified multiple times")
+	flag.StringVar(&config.ParamFile, "param-file", "",
+		"Specify json file with values of parameters, e.g. {\"Name\": \"value\"}")
+	flag.StringVar(&config.OutputFile, "output-file", "",
+		"Write outputs of the stack to the file as json after resources are created")
+	flag.IntVar(&config.Retries, "retries", 5,
+		"Specify max times a failed api call is retried, 0 disables retrying")
+	flag.DurationVar(&config.RetryDelay, "retry-delay", time.Second,
+		"Specify delay before the first retry, which is doubled for each latter retry")
+	flag.DurationVar(&config.RetryMaxDelay, "retry-max-delay", 30*time.Second,
+		"Specify max delay before a retry")
+	flag.DurationVar(&config.RequestTimeout, "request-timeout", 5*time.Minute,
+		"Specify timeout of each api request, 0 disables the timeout")
+	flag.DurationVar(&config.Timeout, "timeout", 0,
+		"Specify timeout of the whole run, e.g. 2h, 0 disables the timeout")
+	flag.StringVar(&config.OpenAPISpec, "openapi-spec", "",
+		"Specify openapi spec file used instead of getting it from ClusterURL")
+	flag.StringVar(&config.CAFile, "ca-file", "",
+		"Specify PEM file of CA certificates to verify certificate of https ClusterURL")
+	flag.StringVar(&config.CertFile, "cert-file", "",
+		"Specify PEM file of client certificate for https ClusterURL")
+	flag.StringVar(&config.KeyFile, "key-file", "",
+		"Specify PEM file of private key of client certificate")
+	flag.StringVar(&config.TLSServerName, "tls-server-name", "",
+		"Specify server name to verify certificate, host of ClusterURL is used by default")
+	flag.BoolVar(&config.Insecure, "insecure", false,
+		"Do not verify certificate of https ClusterURL, which is insecure")
 	flag.StringVar(&config.Token, "t", "",
 		"Specify initial token, auth token or access token for creating resource")
 	flag.StringVar(&templateFile, "f", "", "The formation template file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [%s|%s|%s|%s|%s] [options]\n",
+			os.Args[0], commandCreate, commandPlan, commandDestroy, commandValidate, commandSchema)
+		flag.PrintDefaults()
+	}
 }
 
 func main() {
-	flag.Parse()
+	command := commandCreate
+	args := os.Args[1:]
+	if len(args) != 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	flag.CommandLine.Parse(args)
 	if version {
 		fmt.Println(formation.DetailedVersion())
 		return
 	}
+	// schema is written to stdout without logs so that it could be redirected to a file
+	if command == commandSchema {
+		data, err := formation.TemplateSchema()
+		if err != nil {
+			log.Fatalf("failed to generate schema: %s", errors.ErrorStack(err))
+		}
+		fmt.Println(string(data))
+		return
+	}
 
+	// sensitive values, e.g. passwords and tokens, are redacted in logs
+	log.SetOutput(utils.NewRedactWriter(os.Stderr))
 	log.Println(formation.Version())
+	switch command {
+	case commandCreate, commandDestroy, commandValidate:
+	case commandPlan:
+		if config.DryRun {
+			log.Fatal("dry-run can't be used with plan")
+		}
+		config.Plan = true
+	default:
+		log.Fatalf("unknown command %s", command)
+	}
 	if templateFile == "" {
 		log.Fatal("template file is required")
 	}
+	if command == commandValidate {
+		validate()
+		return
+	}
 
+	ctx, cancel := context.WithCancel(utils.NewContext())
+	defer cancel()
+	if config.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, config.Timeout)
+		defer cancelTimeout()
+	}
 	stack := new(formation.Stack)
-	err := stack.Init(templateFile)
+	go handleInterrupt(stack, cancel)
+
+	err := stack.Init(ctx, templateFile)
 	if err != nil {
 		log.Fatalf("failed to init stack using template %s: %s", templateFile, errors.ErrorStack(err))
 	}
-	stack.Create()
+	switch command {
+	case commandPlan:
+		stack.Plan(ctx)
+	case commandDestroy:
+		stack.Destroy(ctx)
+	default:
+		stack.Create(ctx)
+	}
 
 	return
 }
+
+// handleInterrupt stops the stack on the first interrupt, so that resources in progress are
+// finished and recorded, and cancels them on the second interrupt
+func handleInterrupt(stack *formation.Stack, cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	log.Printf("%s received, waiting for resources in progress, interrupt again to cancel them", sig)
+	stack.Stop()
+	sig = <-signals
+	log.Printf("%s received, cancel resources in progress", sig)
+	cancel()
+}
+
+// validate checks the template without connecting to the cluster, and exits with error if
+// any problem is found
+func validate() {
+	problems, err := formation.ValidateTemplate(templateFile)
+	if err != nil {
+		log.Fatalf("failed to validate template %s: %s", templateFile, errors.ErrorStack(err))
+	}
+	if len(problems) != 0 {
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		log.Fatalf("%d problem(s) found in template %s", len(problems), templateFile)
+	}
+	log.Printf("template %s is valid", templateFile)
+}
@@ -1,12 +1,51 @@
 package config
 
+import "time"
+
 var (
 	// DryRun indicates not create resource really
 	DryRun = false
+	// Plan indicates only get resources from server and record requests of changing resources
+	Plan = false
 	// Token indicates currently used token
 	Token = ""
 	// CachePath cache record path
 	CachePath = "./formation_cache"
 	// NoContinue do not continue from last unfinish run
 	NoContinue = false
+	// Workers max number of resources created at the same time
+	Workers = 1
+	// ParamFile path of json file with values of parameters
+	ParamFile = ""
+	// Params values of parameters set in command line
+	Params = map[string]string{}
+	// OutputFile path of file which outputs of stack are written to as json
+	OutputFile = ""
+	// StateFile path of stack state file, a file in CachePath is used if not set
+	StateFile = ""
+	// Retries max times a failed api call is retried
+	Retries = 5
+	// RetryDelay delay before the first retry of a failed api call, which is doubled for
+	// each latter retry
+	RetryDelay = time.Second
+	// RetryMaxDelay max delay before a retry of a failed api call
+	RetryMaxDelay = 30 * time.Second
+	// RequestTimeout timeout of each api request, requests timed out are retried as failed
+	// requests, requests don't time out if it's 0
+	RequestTimeout = 5 * time.Minute
+	// Timeout timeout of the whole run, the run doesn't time out if it's 0
+	Timeout = time.Duration(0)
+	// OpenAPISpec path of openapi spec file used instead of spec got from server
+	OpenAPISpec = ""
+	// CAFile path of CA certificates to verify certificate of https server
+	CAFile = ""
+	// CertFile path of client certificate for https server
+	CertFile = ""
+	// KeyFile path of private key of client certificate
+	KeyFile = ""
+	// TLSServerName name of server to verify its certificate, host of ClusterURL is used
+	// if not set
+	TLSServerName = ""
+	// Insecure indicates not verify certificate of https server
+	Insecure = false
 )
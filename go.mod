@@ -13,4 +13,6 @@ require (
 	golang.org/x/net v0.0.0-20190620200207-3b0461eec859
 	golang.org/x/oauth2 v0.0.0-20170507214737-e7a48207996f
 	google.golang.org/appengine v0.0.0-20170410194355-170382fa85b1
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,216 @@
+package formation
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
+
+// resourceNode is a resource in the dependency graph of resources
+type resourceNode struct {
+	resource *ResourceInTemplate
+	// indexes of resources which should be done before the resource
+	dependencies []int
+}
+
+// buildResourceGraph builds dependency graph of resources, references to names not found in
+// former resources, such as parameters, are ignored, a resource depends on:
+//  1. the last former resource with the same name, e.g. resource updated by it
+//  2. the last former resources with names referenced by its properties
+//  3. all former resources if it's a template or token resource, and all latter
+//     resources depend on it
+//  4. the last former resource with sleep, since the sleep is waited by latter resources
+//  5. all other resources with names in its DependsOn
+func buildResourceGraph(resources []*ResourceInTemplate) ([]*resourceNode, error) {
+	nameIndexes := map[string][]int{}
+	for i, r := range resources {
+		nameIndexes[r.Name] = append(nameIndexes[r.Name], i)
+	}
+
+	nodes := make([]*resourceNode, 0, len(resources))
+	lastIndexes := map[string]int{}
+	barrier := -1
+	for i, r := range resources {
+		node := &resourceNode{resource: r}
+		dependencies := map[int]bool{}
+		if isSerialResource(r) {
+			for j := 0; j < i; j++ {
+				dependencies[j] = true
+			}
+		} else {
+			if barrier >= 0 {
+				dependencies[barrier] = true
+			}
+			if index, ok := lastIndexes[r.Name]; ok {
+				dependencies[index] = true
+			}
+			for _, name := range r.Properties.References() {
+				if index, ok := lastIndexes[name]; ok {
+					dependencies[index] = true
+				}
+			}
+		}
+		for _, name := range r.DependsOn {
+			indexes, ok := nameIndexes[name]
+			if !ok {
+				return nil, errors.NotFoundf("resource %s in DependsOn of %s", name, r.Name)
+			}
+			for _, index := range indexes {
+				if index != i {
+					dependencies[index] = true
+				}
+			}
+		}
+		for j := range resources {
+			if dependencies[j] {
+				node.dependencies = append(node.dependencies, j)
+			}
+		}
+		nodes = append(nodes, node)
+
+		lastIndexes[r.Name] = i
+		if isSerialResource(r) || r.Sleep > 0 {
+			barrier = i
+		}
+	}
+	if err := checkResourceGraph(nodes); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return nodes, nil
+}
+
+// checkResourceGraph returns error if there is a cycle in the graph
+func checkResourceGraph(nodes []*resourceNode) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make([]int, len(nodes))
+	var path []string
+	var visit func(index int) error
+	visit = func(index int) error {
+		node := nodes[index]
+		path = append(path, node.resource.Name)
+		switch states[index] {
+		case visiting:
+			return errors.Errorf("dependency cycle found: %s", strings.Join(path, " -> "))
+		case visited:
+			path = path[:len(path)-1]
+			return nil
+		}
+		states[index] = visiting
+		for _, dependency := range node.dependencies {
+			if err := visit(dependency); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		states[index] = visited
+		path = path[:len(path)-1]
+		return nil
+	}
+	for i := range nodes {
+		if err := visit(i); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// isSerialResource returns whether the resource should be handled without any other
+// resources in progress, template resource changes contexts of the stack and token
+// resource changes token used by all resources
+func isSerialResource(r *ResourceInTemplate) bool {
+	return r.Type == utils.ResourceTemplate || r.Type == utils.ResourceToken
+}
+
+// resourceResult is result of handling a resource
+type resourceResult struct {
+	index      int
+	key        string
+	restored   bool
+	skipped    bool
+	repr       interface{}
+	rType      string
+	action     string
+	properties map[string]interface{}
+	err        error
+	// identifies of items adopted by a list resource which is partly created
+	adoptedItems []string
+}
+
+// runResourceGraph handles resources from index start in the graph with at most workers
+// resources in progress at the same time, a resource is started once all its
+// dependencies are done, and results are committed in order of resources by commit.
+// If any resource fails, no more resources are started, and results of all resources
+// done are committed once resources in progress are finished, so that resources done
+// after the failed one are recorded too.
+func runResourceGraph(nodes []*resourceNode, start, workers int,
+	handle func(index int) *resourceResult, commit func(result *resourceResult)) error {
+
+	if workers < 1 {
+		workers = 1
+	}
+	done := make([]bool, len(nodes))
+	for i := 0; i < start; i++ {
+		done[i] = true
+	}
+	started := make([]bool, len(nodes))
+	results := make([]*resourceResult, len(nodes))
+	resultChan := make(chan *resourceResult)
+	next, running := start, 0
+	var err error
+	for {
+		for i := start; err == nil && i < len(nodes) && running < workers; i++ {
+			if started[i] || !isNodeReady(nodes[i], done) {
+				continue
+			}
+			started[i] = true
+			running++
+			go func(index int) {
+				resultChan <- handle(index)
+			}(i)
+		}
+		if running == 0 {
+			break
+		}
+		result := <-resultChan
+		running--
+		if result.err != nil {
+			if err == nil {
+				err = result.err
+			}
+			continue
+		}
+		done[result.index] = true
+		results[result.index] = result
+		for next < len(nodes) && results[next] != nil {
+			commit(results[next])
+			next++
+		}
+	}
+	if err != nil {
+		for i := next; i < len(nodes); i++ {
+			if results[i] != nil {
+				commit(results[i])
+			}
+		}
+		return errors.Trace(err)
+	}
+	if next < len(nodes) {
+		return errors.Errorf("resource %s can't be handled for lack of dependencies",
+			nodes[next].resource.Name)
+	}
+	return nil
+}
+
+func isNodeReady(node *resourceNode, done []bool) bool {
+	for _, index := range node.dependencies {
+		if !done[index] {
+			return false
+		}
+	}
+	return true
+}
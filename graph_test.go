@@ -0,0 +1,152 @@
+package formation
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type resourceGraphSuite struct {
+	suite.Suite
+}
+
+func (s *resourceGraphSuite) parseResources(data string) []*ResourceInTemplate {
+	var resources []*ResourceInTemplate
+	s.NoError(json.Unmarshal([]byte(data), &resources))
+	for _, r := range resources {
+		r.Properties.Init(new(Stack))
+	}
+	return resources
+}
+
+func (s *resourceGraphSuite) getDependencies(nodes []*resourceNode) [][]int {
+	dependencies := make([][]int, 0, len(nodes))
+	for _, node := range nodes {
+		dependencies = append(dependencies, node.dependencies)
+	}
+	return dependencies
+}
+
+func (s *resourceGraphSuite) TestBuildResourceGraph() {
+	resources := s.parseResources(`[
+		{"Name": "token", "Type": "Token", "Properties": {"Name": {"Ref": "name"}, "Password": "pwd"}},
+		{"Name": "disks", "Type": "DiskList", "Properties": {"Used": false}},
+		{"Name": "osd", "Type": "Osd", "Properties": {"DiskID": {"Select": [0, {"Ref": "disks"}]}}},
+		{"Name": "pool1", "Type": "Pool", "Properties": {"Name": "pool1", "OsdIDs": [{"Ref": "osd"}]}},
+		{"Name": "pool2", "Type": "Pool", "Properties": {"Name": "pool2", "OsdIDs": [{"Ref": "osd"}]}},
+		{"Name": "pool2", "Type": "Pool", "Action": "Update", "Sleep": 1,
+			"Properties": {"Name": "pool2"}},
+		{"Name": "pool3", "Type": "Pool", "Properties": {"Name": "pool3"}}
+	]`)
+
+	nodes, err := buildResourceGraph(resources)
+	s.NoError(err)
+	s.Equal([][]int{nil, {0}, {0, 1}, {0, 2}, {0, 2}, {0, 4}, {5}}, s.getDependencies(nodes))
+}
+
+func (s *resourceGraphSuite) TestBuildResourceGraphWithDependsOn() {
+	resources := s.parseResources(`[
+		{"Name": "host", "Type": "Host", "Properties": {"AdminIP": "10.0.0.1"}},
+		{"Name": "pool", "Type": "Pool", "DependsOn": ["gateway"], "Properties": {"Name": "pool1"}},
+		{"Name": "gateway", "Type": "NFSGateway", "DependsOn": ["host"],
+			"Properties": {"Name": "gw1", "HostID": 1}}
+	]`)
+
+	nodes, err := buildResourceGraph(resources)
+	s.NoError(err)
+	s.Equal([][]int{nil, {2}, {0}}, s.getDependencies(nodes))
+
+	resources[0].DependsOn = []string{"pool"}
+	_, err = buildResourceGraph(resources)
+	s.EqualError(err, "dependency cycle found: host -> pool -> gateway -> host")
+
+	resources[0].DependsOn = []string{"volume"}
+	_, err = buildResourceGraph(resources)
+	s.True(errors.IsNotFound(err))
+}
+
+func (s *resourceGraphSuite) TestRunResourceGraph() {
+	nodes := []*resourceNode{
+		{resource: &ResourceInTemplate{Name: "a"}},
+		{resource: &ResourceInTemplate{Name: "b"}},
+		{resource: &ResourceInTemplate{Name: "c"}},
+		{resource: &ResourceInTemplate{Name: "d"}, dependencies: []int{0, 1}},
+	}
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	handle := func(index int) *resourceResult {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		// the first resource is done at last
+		time.Sleep(time.Duration(3-index) * 10 * time.Millisecond)
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return &resourceResult{index: index}
+	}
+	var committed []int
+	commit := func(result *resourceResult) {
+		committed = append(committed, result.index)
+	}
+
+	s.NoError(runResourceGraph(nodes, 0, 2, handle, commit))
+	s.Equal([]int{0, 1, 2, 3}, committed)
+	s.Equal(2, maxRunning)
+
+	committed = nil
+	s.NoError(runResourceGraph(nodes, 2, 2, handle, commit))
+	s.Equal([]int{2, 3}, committed)
+}
+
+func (s *resourceGraphSuite) TestRunResourceGraphWithError() {
+	nodes := []*resourceNode{
+		{resource: &ResourceInTemplate{Name: "a"}},
+		{resource: &ResourceInTemplate{Name: "b"}, dependencies: []int{0}},
+	}
+	handled := 0
+	handle := func(index int) *resourceResult {
+		handled++
+		return &resourceResult{index: index, err: errors.New("failed")}
+	}
+	commit := func(result *resourceResult) {
+		s.Fail("result with error should not be committed")
+	}
+
+	s.Error(runResourceGraph(nodes, 0, 2, handle, commit))
+	s.Equal(1, handled)
+}
+
+func (s *resourceGraphSuite) TestCommitAfterError() {
+	nodes := []*resourceNode{
+		{resource: &ResourceInTemplate{Name: "a"}},
+		{resource: &ResourceInTemplate{Name: "b"}},
+		{resource: &ResourceInTemplate{Name: "c"}, dependencies: []int{0}},
+	}
+	handle := func(index int) *resourceResult {
+		if index == 0 {
+			// the first resource fails after the second one is done
+			time.Sleep(20 * time.Millisecond)
+			return &resourceResult{index: index, err: errors.New("failed")}
+		}
+		return &resourceResult{index: index}
+	}
+	var committed []int
+	commit := func(result *resourceResult) {
+		committed = append(committed, result.index)
+	}
+
+	s.EqualError(runResourceGraph(nodes, 0, 2, handle, commit), "failed")
+	s.Equal([]int{1}, committed)
+}
+
+func TestResourceGraphSuite(t *testing.T) {
+	suite.Run(t, new(resourceGraphSuite))
+}
@@ -0,0 +1,177 @@
+package formation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/parser"
+)
+
+// module defines a reusable template loaded from a separate file, its parameters are set
+// by context of the template resource and its outputs are exported as values of the
+// template resource
+type module struct {
+	Description string
+	Parameters  map[string]*Parameter
+	Resources   json.RawMessage
+	Outputs     map[string]*Output
+
+	path string
+	// names of parameters without default value
+	required map[string]bool
+}
+
+// moduleExts are extensions of module files loaded from a directory
+var moduleExts = []string{".json", ".yaml", ".yml"}
+
+func loadModule(path string) (*module, error) {
+	data, err := readTemplateFile(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	m := &module{path: path, required: map[string]bool{}}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, errors.Annotatef(err, "parse module %s", path)
+	}
+	if len(m.Resources) == 0 {
+		return nil, errors.Errorf("Resources is required for module %s", path)
+	}
+	rawParams := struct {
+		Parameters map[string]map[string]json.RawMessage
+	}{}
+	if err = json.Unmarshal(data, &rawParams); err != nil {
+		return nil, errors.Annotatef(err, "parse parameters of module %s", path)
+	}
+	for name, attributes := range rawParams.Parameters {
+		if _, ok := attributes["Value"]; !ok {
+			m.required[name] = true
+		}
+	}
+	return m, nil
+}
+
+// loadImports loads modules in Imports of template, paths of modules are relative to baseDir.
+// All modules in a directory are imported as <name>/<file name without extension>.
+func (t *Template) loadImports(baseDir string) error {
+	if len(t.Imports) == 0 {
+		return nil
+	}
+	t.modules = make(map[string]*module)
+	if t.Templates == nil {
+		t.Templates = make(map[string]json.RawMessage)
+	}
+	addModule := func(name, path string) error {
+		if _, ok := t.Templates[name]; ok {
+			return errors.AlreadyExistsf("template %s imported from %s", name, path)
+		}
+		m, err := loadModule(path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		t.modules[name] = m
+		t.Templates[name] = m.Resources
+		return nil
+	}
+
+	for name, path := range t.Imports {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.Annotatef(err, "import %s", name)
+		}
+		if !info.IsDir() {
+			if err = addModule(name, path); err != nil {
+				return errors.Annotatef(err, "import %s", name)
+			}
+			continue
+		}
+		files, err := ioutil.ReadDir(path)
+		if err != nil {
+			return errors.Annotatef(err, "import %s", name)
+		}
+		for _, file := range files {
+			ext := filepath.Ext(file.Name())
+			if file.IsDir() || !isModuleExt(ext) {
+				continue
+			}
+			moduleName := name + "/" + strings.TrimSuffix(file.Name(), ext)
+			if err = addModule(moduleName, filepath.Join(path, file.Name())); err != nil {
+				return errors.Annotatef(err, "import %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+func isModuleExt(ext string) bool {
+	for _, moduleExt := range moduleExts {
+		if ext == moduleExt {
+			return true
+		}
+	}
+	return false
+}
+
+// setParameters sets parameters of module in template context, values of parameters
+// not set in context are their default values
+func (m *module) setParameters(context map[string]interface{}) error {
+	for name, value := range context {
+		param, ok := m.Parameters[name]
+		if !ok {
+			return errors.NotFoundf("parameter %s of module %s", name, m.path)
+		}
+		if reflect.TypeOf(value) != reflect.TypeOf(param.Value) {
+			return errors.Errorf("value %v of parameter %s of module %s should be %s",
+				value, name, m.path, param.Type)
+		}
+		// check constraints of parameter with value in context
+		contextParam := *param
+		contextParam.Value = value
+		if err := contextParam.Validate(); err != nil {
+			return errors.Annotatef(err, "invalid value %v of parameter %s of module %s",
+				value, name, m.path)
+		}
+	}
+
+	var missing []string
+	for name, param := range m.Parameters {
+		if _, ok := context[name]; ok {
+			continue
+		}
+		if m.required[name] {
+			missing = append(missing, name)
+			continue
+		}
+		context[name] = param.Value
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return errors.Errorf("parameters %s of module %s are required",
+			strings.Join(missing, ","), m.path)
+	}
+	return nil
+}
+
+// getOutputs returns values of outputs of module, it should be called in the template
+// instantiating the module
+func (m *module) getOutputs(s *Stack) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{}, len(m.Outputs))
+	for name, output := range m.Outputs {
+		value, err := parser.GetExprValue(s, output.Value)
+		if err != nil {
+			return nil, errors.Annotatef(err, "get value of output %s of module %s", name, m.path)
+		}
+		outputs[name] = value
+	}
+	return outputs, nil
+}
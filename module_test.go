@@ -0,0 +1,158 @@
+package formation
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/suite"
+
+	"xsky.com/sds-formation/config"
+)
+
+type moduleSuite struct {
+	suite.Suite
+
+	dir         string
+	stack       *Stack
+	oldOpenFile OpenFileFunc
+}
+
+func (s *moduleSuite) SetupTest() {
+	config.Plan = true
+	s.oldOpenFile = OpenFile
+	OpenFile = realOpenFile
+
+	var err error
+	s.dir, err = ioutil.TempDir("", "formation-module")
+	s.NoError(err)
+	s.NoError(os.Mkdir(filepath.Join(s.dir, "lib"), 0755))
+	s.NoError(ioutil.WriteFile(filepath.Join(s.dir, "lib", "names.json"), []byte(`{
+		"Description": "names of volumes",
+		"Parameters": {
+			"prefix": {"Type": "String", "AllowedPattern": "[a-z]+"},
+			"count": {"Type": "Integer", "Value": 2}
+		},
+		"Resources": [{
+			"Name": "names",
+			"Type": "StringList",
+			"Properties": {"Attributes": [{"Sub": "${prefix}-${count}"}, {"Ref": "site"}]}
+		}],
+		"Outputs": {
+			"Names": {"Type": "String", "Value": {"Join": [",", {"Ref": "names"}]}}
+		}
+	}`), 0644))
+	s.NoError(ioutil.WriteFile(filepath.Join(s.dir, "lib", "README.md"), []byte("modules"), 0644))
+
+	s.stack = new(Stack)
+	s.stack.token = "28171a13317c4252806979bc86a69c4f"
+	s.stack.resourceValueMap = map[string]interface{}{"site": "dc1"}
+	s.stack.template = new(Template)
+}
+
+func (s *moduleSuite) TearDownTest() {
+	config.Plan = false
+	OpenFile = s.oldOpenFile
+	os.RemoveAll(s.dir)
+}
+
+func (s *moduleSuite) TestCreateModule() {
+	s.NoError(json.Unmarshal([]byte(`{
+		"Imports": {"lib": "lib", "volume_names": "lib/names.json"},
+		"Resources": [{
+			"Name": "volumes",
+			"Type": "Template",
+			"TemplateName": "lib/names",
+			"Context": [{"Name": "prefix", "Type": "StringList", "Value": ["vol", "img"], "Action": "range"}]
+		}, {
+			"Name": "volume",
+			"Type": "Template",
+			"TemplateName": "volume_names",
+			"Context": [
+				{"Name": "prefix", "Type": "String", "Value": "vol"},
+				{"Name": "count", "Type": "Integer", "Value": 3}
+			]
+		}]
+	}`), s.stack.template))
+	s.NoError(s.stack.template.loadImports(s.dir))
+	s.Len(s.stack.template.modules, 2)
+	s.NoError(s.stack.template.CheckTemplates())
+
+	s.NoError(s.stack.CreateResources(context.Background(), s.stack.template.Resources))
+	s.Equal([]map[string]interface{}{
+		{"names": []string{"vol-2", "dc1"}, "Names": "vol-2,dc1"},
+		{"names": []string{"img-2", "dc1"}, "Names": "img-2,dc1"},
+	}, s.stack.GetResourceValue("volumes"))
+	s.Equal([]map[string]interface{}{
+		{"names": []string{"vol-3", "dc1"}, "Names": "vol-3,dc1"},
+	}, s.stack.GetResourceValue("volume"))
+}
+
+func (s *moduleSuite) TestFromFileRelativeToTemplate() {
+	s.NoError(ioutil.WriteFile(filepath.Join(s.dir, "secret"), []byte("main-secret\n"), 0600))
+	s.NoError(ioutil.WriteFile(filepath.Join(s.dir, "lib", "secret"), []byte("lib-secret\n"), 0600))
+	s.NoError(ioutil.WriteFile(filepath.Join(s.dir, "lib", "files.yaml"), []byte(`
+Resources:
+  - {Name: content, Type: StringList, Properties: {Attributes: [{FromFile: secret}]}}
+`), 0644))
+	templatePath := filepath.Join(s.dir, "main.json")
+	s.NoError(ioutil.WriteFile(templatePath, []byte(`{
+		"Imports": {"files": "lib/files.yaml"},
+		"Resources": [
+			{"Name": "content", "Type": "StringList", "Properties": {"Attributes": [{"FromFile": "secret"}]}},
+			{"Name": "files", "Type": "Template", "TemplateName": "files"}
+		]
+	}`), 0644))
+
+	// files are found next to the template instead of the working directory
+	wd, err := os.Getwd()
+	s.NoError(err)
+	defer os.Chdir(wd)
+	s.NoError(os.Chdir(os.TempDir()))
+
+	data, err := readTemplateFile(templatePath)
+	s.NoError(err)
+	s.NoError(json.Unmarshal(data, s.stack.template))
+	s.NoError(s.stack.template.loadImports(s.dir))
+	s.NoError(s.stack.template.CheckTemplates())
+
+	s.NoError(s.stack.CreateResources(context.Background(), s.stack.template.Resources))
+	s.Equal([]string{"main-secret"}, s.stack.GetResourceValue("content"))
+	s.Equal([]map[string]interface{}{{"content": []string{"lib-secret"}}},
+		s.stack.GetResourceValue("files"))
+}
+
+func (s *moduleSuite) TestSetParameters() {
+	m, err := loadModule(filepath.Join(s.dir, "lib", "names.json"))
+	s.NoError(err)
+	s.Equal(map[string]bool{"prefix": true}, m.required)
+
+	context := map[string]interface{}{"prefix": "vol"}
+	s.NoError(m.setParameters(context))
+	s.Equal(map[string]interface{}{"prefix": "vol", "count": int64(2)}, context)
+
+	err = m.setParameters(map[string]interface{}{"count": int64(1)})
+	s.Error(err)
+	err = m.setParameters(map[string]interface{}{"prefix": "vol", "size": int64(1)})
+	s.True(errors.IsNotFound(err))
+	err = m.setParameters(map[string]interface{}{"prefix": "vol", "count": "1"})
+	s.Error(err)
+	err = m.setParameters(map[string]interface{}{"prefix": "VOL"})
+	s.Error(err)
+}
+
+func (s *moduleSuite) TestLoadImportsWithError() {
+	s.stack.template.Imports = map[string]string{"lib": "none"}
+	s.Error(s.stack.template.loadImports(s.dir))
+
+	s.stack.template.Imports = map[string]string{"lib/names": "lib/names.json", "lib": "lib"}
+	s.True(errors.IsAlreadyExists(errors.Cause(s.stack.template.loadImports(s.dir))))
+}
+
+func TestModuleSuite(t *testing.T) {
+	suite.Run(t, new(moduleSuite))
+}
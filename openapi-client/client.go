@@ -2,11 +2,18 @@ package openapiclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
+	"math/rand"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/juju/errors"
 )
@@ -84,15 +91,92 @@ func (o *openAPIInfo) UnmarshalJSON(bytes []byte) error {
 	return nil
 }
 
+// APIError defines error of an api call responded by server
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status: %s, body: %s", e.Status, e.Body)
+}
+
+// IsNotFound returns if the error is caused by a not found response
+func IsNotFound(err error) bool {
+	apiErr, ok := errors.Cause(err).(*APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// isUnauthorized returns if the error is caused by an unauthorized response, e.g. token
+// is expired
+func isUnauthorized(err error) bool {
+	apiErr, ok := errors.Cause(err).(*APIError)
+	return ok && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// RetryPolicy defines how failed api calls are retried, a call is retried on transport
+// errors, 429 and 5xx responses with exponential backoff
+type RetryPolicy struct {
+	// MaxRetries max times a call is retried, calls are not retried if it's 0
+	MaxRetries int
+	// BaseDelay delay before the first retry, which is doubled for each latter retry
+	BaseDelay time.Duration
+	// MaxDelay max delay before a retry
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is retry policy of clients returned by NewOpenAPIClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 5,
+	BaseDelay:  time.Second,
+	MaxDelay:   30 * time.Second,
+}
+
+// delay returns delay before the retry, which is a random duration between half and all of
+// the backoff so that clients failed at the same time don't retry at the same time
+func (p RetryPolicy) delay(retry int) time.Duration {
+	backoff := p.BaseDelay
+	for i := 0; i < retry && backoff < p.MaxDelay; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// CreatedCheck checks whether a resource is created by a failed create call, since the
+// server could create it before the connection is dropped. Body of the created resource
+// is returned, or nil if it's not created.
+type CreatedCheck func(ctx context.Context) ([]byte, error)
+
+// CredentialProvider returns a new token when the token of client is expired, i.e. an api
+// call is responded with 401. Apis called to get the token should be called by the client
+// passed in, which doesn't re-authenticate.
+type CredentialProvider func(ctx context.Context, client Client) (string, error)
+
 // Client defines interface of openapi client
 type Client interface {
 	Init() error
 	SetServer(string)
 	SetToken(string)
-	LoadSpec() error
+	SetRetryPolicy(RetryPolicy)
+	SetCredentialProvider(CredentialProvider)
+	SetTimeout(time.Duration)
+	SetSpecFile(string)
+	SetSpecCacheDir(string)
+	LoadSpec(context.Context) error
 	ServerVersion() string
 	OpenAPIVersion() string
-	CallAPI(string, interface{}, map[string]string, ...map[string]string) ([]byte, error)
+	HasOperation(string) bool
+	CallAPI(context.Context, string, interface{}, map[string]string,
+		...map[string]string) ([]byte, error)
+	CallCreateAPI(context.Context, string, interface{}, map[string]string, CreatedCheck,
+		...map[string]string) ([]byte, error)
 }
 
 type httpClient interface {
@@ -103,13 +187,52 @@ type httpClient interface {
 type client struct {
 	httpClient
 
-	openAPI *openAPIInfo
-	server  string
-	token   string
+	openAPI     *openAPIInfo
+	tlsOptions  *TLSOptions
+	server      string
+	token       string
+	retryPolicy RetryPolicy
+	sleep       func(context.Context, time.Duration) error
+	// timeout of each request, requests don't time out if it's 0
+	timeout time.Duration
+	// spec is loaded from specFile instead of server if it's set, and specs got from server
+	// are cached in specCacheDir if it's set
+	specFile     string
+	specCacheDir string
+
+	// mutex protects token and re-authentication, since apis are called concurrently
+	mutex              sync.Mutex
+	credentialProvider CredentialProvider
+	// closed once the token is re-authenticated
+	reauthenticating chan struct{}
 }
 
 func (c *client) Init() error {
-	c.httpClient = new(http.Client)
+	tlsConfig, err := c.tlsOptions.tlsConfig()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if tlsConfig == nil {
+		c.httpClient = new(http.Client)
+		return nil
+	}
+	if tlsConfig.InsecureSkipVerify {
+		log.Printf("WARNING: certificate of server is not verified, " +
+			"connections to it could be intercepted")
+	}
+	// same as http.DefaultTransport except TLS config
+	c.httpClient = &http.Client{Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}}
 	return nil
 }
 
@@ -118,40 +241,111 @@ func (c *client) SetServer(url string) {
 }
 
 func (c *client) SetToken(token string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	c.token = token
 }
 
-func (c *client) LoadSpec() error {
-	resp, err := c.Get(strings.TrimSuffix(c.server, "/v1") + "/docs/openapi.json")
-	if err != nil {
-		return errors.Annotate(err, "get openapi spec")
+func (c *client) getToken() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.token
+}
+
+func (c *client) SetCredentialProvider(provider CredentialProvider) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.credentialProvider = provider
+}
+
+func (c *client) getCredentialProvider() CredentialProvider {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.credentialProvider
+}
+
+// reauthenticate gets a new token from credential provider if the token used by the failed
+// call isn't changed, calls failed at the same time wait for the same re-authentication
+func (c *client) reauthenticate(ctx context.Context, usedToken string) error {
+	c.mutex.Lock()
+	if c.token != usedToken {
+		c.mutex.Unlock()
+		return nil
 	}
-	var bytes []byte
-	if resp.Body != nil {
-		bytes, err = ioutil.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return errors.Trace(err)
+	if reauthenticating := c.reauthenticating; reauthenticating != nil {
+		c.mutex.Unlock()
+		select {
+		case <-reauthenticating:
+			return nil
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
 		}
 	}
-	if resp.StatusCode >= http.StatusMultipleChoices {
-		return errors.Errorf("status: %s, body: %s", resp.Status, string(bytes))
-	}
+	reauthenticating := make(chan struct{})
+	c.reauthenticating = reauthenticating
+	provider := c.credentialProvider
+	c.mutex.Unlock()
 
-	if err = c.ParseOpenAPISpec(bytes); err != nil {
-		return errors.Trace(err)
+	log.Printf("token is expired, re-authenticate")
+	// client without token and credential provider is used, since apis getting token don't
+	// require token
+	token, err := provider(ctx, &client{
+		httpClient:  c.httpClient,
+		openAPI:     c.openAPI,
+		server:      c.server,
+		retryPolicy: c.retryPolicy,
+		sleep:       c.sleep,
+		timeout:     c.timeout,
+	})
+	c.mutex.Lock()
+	if err == nil {
+		c.token = token
+	}
+	c.reauthenticating = nil
+	c.mutex.Unlock()
+	close(reauthenticating)
+	if err != nil {
+		return errors.Annotate(err, "re-authenticate")
 	}
 	return nil
 }
 
+func (c *client) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+func (c *client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// withTimeout returns context of a request, which is done once the request times out
+func (c *client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
 func (c *client) ParseOpenAPISpec(bytes []byte) error {
-	c.openAPI = new(openAPIInfo)
-	if err := json.Unmarshal(bytes, c.openAPI); err != nil {
+	openAPI := new(openAPIInfo)
+	if err := json.Unmarshal(bytes, openAPI); err != nil {
 		return errors.Trace(err)
 	}
+	if openAPI.Paths == nil {
+		return errors.Errorf("paths not found in openapi spec")
+	}
+	c.openAPI = openAPI
 	return nil
 }
 
+func (c *client) HasOperation(operationID string) bool {
+	if c.openAPI == nil {
+		return false
+	}
+	_, ok := c.openAPI.Paths.OperationIDs[operationID]
+	return ok
+}
+
 func (c *client) ServerVersion() string {
 	return c.openAPI.Version
 }
@@ -160,11 +354,37 @@ func (c *client) OpenAPIVersion() string {
 	return c.openAPI.OpenAPI
 }
 
-func (c *client) CallAPI(operationID string, body interface{}, pathParams map[string]string,
+// CallAPI calls the api, GET and HEAD calls are retried since they don't change anything
+func (c *client) CallAPI(ctx context.Context, operationID string, body interface{},
+	pathParams map[string]string, queryParams ...map[string]string) ([]byte, error) {
+
+	bytes, err := c.callAPI(ctx, operationID, body, pathParams, nil, queryParams...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return bytes, nil
+}
+
+// CallCreateAPI calls the api which creates a resource, the call is retried only if the
+// resource is not created according to check, otherwise body returned by check is returned.
+// It's not retried if check is nil.
+func (c *client) CallCreateAPI(ctx context.Context, operationID string, body interface{},
+	pathParams map[string]string, check CreatedCheck,
+	queryParams ...map[string]string) ([]byte, error) {
+
+	bytes, err := c.callAPI(ctx, operationID, body, pathParams, check, queryParams...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return bytes, nil
+}
+
+func (c *client) callAPI(ctx context.Context, operationID string, body interface{},
+	pathParams map[string]string, check CreatedCheck,
 	queryParams ...map[string]string) ([]byte, error) {
 
 	if c.openAPI == nil {
-		return nil, nil
+		return nil, errors.Errorf("openapi spec is not loaded")
 	}
 	methodInfo, ok := c.openAPI.Paths.OperationIDs[operationID]
 	if !ok {
@@ -178,48 +398,121 @@ func (c *client) CallAPI(operationID string, body interface{}, pathParams map[st
 		}
 		reqPath = strings.Replace(reqPath, "{"+param+"}", val, -1)
 	}
-	var bodyReader io.Reader
+	var bodyBytes []byte
 	if body != nil {
-		bodyBytes, err := json.Marshal(body)
-		if err != nil {
+		var err error
+		if bodyBytes, err = json.Marshal(body); err != nil {
 			return nil, errors.Trace(err)
 		}
-		bodyReader = bytes.NewReader(bodyBytes)
 	}
-	req, err := http.NewRequest(methodInfo.Method, reqPath, bodyReader)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	if c.token != "" {
-		req.Header.Add("Xms-Auth-Token", c.token)
-	}
-	if len(queryParams) != 0 {
-		q := req.URL.Query()
-		for key, val := range queryParams[0] {
-			q.Add(key, val)
+
+	reauthenticated := false
+	for retry := 0; ; retry++ {
+		// request is created for each call since its body is consumed
+		var bodyReader io.Reader
+		if bodyBytes != nil {
+			bodyReader = bytes.NewReader(bodyBytes)
+		}
+		req, err := http.NewRequest(methodInfo.Method, reqPath, bodyReader)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		token := c.getToken()
+		if token != "" {
+			req.Header.Add("Xms-Auth-Token", token)
+		}
+		if len(queryParams) != 0 {
+			q := req.URL.Query()
+			for key, val := range queryParams[0] {
+				q.Add(key, val)
+			}
+			req.URL.RawQuery = q.Encode()
+		}
+
+		reqCtx, cancel := c.withTimeout(ctx)
+		respBytes, retryable, err := c.do(req.WithContext(reqCtx))
+		cancel()
+		if err == nil {
+			return respBytes, nil
+		}
+		// the call is canceled or the run times out
+		if ctx.Err() != nil {
+			return nil, errors.Annotatef(ctx.Err(), "call %s", operationID)
+		}
+		// expired token is re-authenticated once for each call, and the call is retried
+		// with the new token immediately
+		if isUnauthorized(err) && !reauthenticated && c.getCredentialProvider() != nil {
+			if reauthErr := c.reauthenticate(ctx, token); reauthErr != nil {
+				return nil, errors.Annotatef(err, "%s", reauthErr)
+			}
+			reauthenticated = true
+			retry--
+			continue
+		}
+		if !retryable || retry >= c.retryPolicy.MaxRetries {
+			return nil, errors.Trace(err)
+		}
+		switch {
+		case methodInfo.Method == http.MethodGet || methodInfo.Method == http.MethodHead:
+		case methodInfo.Method == http.MethodPost && check != nil:
+			created, checkErr := check(ctx)
+			if checkErr != nil {
+				return nil, errors.Annotatef(err, "check whether resource is created: %s", checkErr)
+			}
+			if created != nil {
+				log.Printf("Resource is created by failed call %s: %s", operationID, err)
+				return created, nil
+			}
+		default:
+			return nil, errors.Trace(err)
+		}
+		delay := c.retryPolicy.delay(retry)
+		log.Printf("Retry call %s in %s: %s", operationID, delay, err)
+		if err = c.sleep(ctx, delay); err != nil {
+			return nil, errors.Annotatef(err, "call %s", operationID)
 		}
-		req.URL.RawQuery = q.Encode()
 	}
+}
+
+// do sends the request and returns body of the response, and whether the request could be
+// retried if it's failed
+func (c *client) do(req *http.Request) ([]byte, bool, error) {
 	resp, err := c.Do(req)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, true, errors.Trace(err)
 	}
 	var bytes []byte
 	if resp.Body != nil {
 		bytes, err = ioutil.ReadAll(resp.Body)
 		resp.Body.Close()
 		if err != nil {
-			return nil, errors.Trace(err)
+			return nil, true, errors.Trace(err)
 		}
 	}
 	if resp.StatusCode >= 300 {
-		return nil, errors.Errorf("status: %s, body: %s", resp.Status, string(bytes))
+		retryable := resp.StatusCode == http.StatusTooManyRequests ||
+			resp.StatusCode >= http.StatusInternalServerError
+		return nil, retryable, errors.Trace(&APIError{
+			StatusCode: resp.StatusCode, Status: resp.Status, Body: string(bytes)})
 	}
 
-	return bytes, nil
+	return bytes, false, nil
 }
 
-// NewOpenAPIClient returns a openapi client instance
-func NewOpenAPIClient() Client {
-	return &client{}
+// NewOpenAPIClient returns a openapi client instance, connections to https server are
+// configured by tlsOptions, which could be nil
+func NewOpenAPIClient(tlsOptions *TLSOptions) Client {
+	return &client{tlsOptions: tlsOptions, retryPolicy: DefaultRetryPolicy, sleep: sleepWithContext}
+}
+
+// sleepWithContext pauses for the duration, error is returned if the context is done before
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	}
 }
@@ -1,10 +1,13 @@
 package openapiclient
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -194,24 +197,248 @@ func (s *callAPISuite) TestCallAPI() {
 			Body:       ioutil.NopCloser(strings.NewReader("{}")),
 		}, nil)
 
-	_, err := s.apiClient.CallAPI("op1", nil, nil)
+	_, err := s.apiClient.CallAPI(context.Background(), "op1", nil, nil)
 	assert.NoError(s.T(), err)
 
 	mockedClient.AssertCalled(s.T(), "Do", mock.AnythingOfType("*http.Request"))
 }
 
 func (s *callAPISuite) TestCallWithouPathParam() {
-	_, err := s.apiClient.CallAPI("op2", nil, nil)
+	_, err := s.apiClient.CallAPI(context.Background(), "op2", nil, nil)
 	assert.Error(s.T(), err)
 
 	assert.EqualError(s.T(), err, "path param test not set")
 }
 
 func (s *callAPISuite) TestWithNotFoundOperationID() {
-	_, err := s.apiClient.CallAPI("test33", nil, nil)
+	_, err := s.apiClient.CallAPI(context.Background(), "test33", nil, nil)
 	assert.EqualError(s.T(), err, "operation id test33 not found")
 }
 
+func (s *callAPISuite) TestCallAPIWithNotFoundResp() {
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Status:     "404 Not Found",
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader("{}")),
+		}, nil)
+
+	_, err := s.apiClient.CallAPI(context.Background(), "op1", nil, nil)
+	assert.EqualError(s.T(), err, "status: 404 Not Found, body: {}")
+	assert.True(s.T(), IsNotFound(err))
+}
+
+func newResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(statusCode),
+		StatusCode: statusCode,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func (s *callAPISuite) setRetryPolicy() *[]time.Duration {
+	delays := new([]time.Duration)
+	s.apiClient.SetRetryPolicy(RetryPolicy{
+		MaxRetries: 2, BaseDelay: time.Second, MaxDelay: 30 * time.Second})
+	s.apiClient.sleep = func(_ context.Context, delay time.Duration) error {
+		*delays = append(*delays, delay)
+		return nil
+	}
+	return delays
+}
+
+func (s *callAPISuite) TestRetryGet() {
+	delays := s.setRetryPolicy()
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusServiceUnavailable, "{}"), nil).Once()
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return((*http.Response)(nil), errors.New("connection reset")).Once()
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusOK, `{"a": 1}`), nil).Once()
+
+	body, err := s.apiClient.CallAPI(context.Background(), "op1", nil, nil)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), `{"a": 1}`, string(body))
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 3)
+	assert.Len(s.T(), *delays, 2)
+}
+
+func (s *callAPISuite) TestRetryGetExhausted() {
+	s.setRetryPolicy()
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	for i := 0; i < 3; i++ {
+		mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+			Return(newResponse(http.StatusTooManyRequests, "{}"), nil).Once()
+	}
+
+	_, err := s.apiClient.CallAPI(context.Background(), "op1", nil, nil)
+	assert.EqualError(s.T(), err, "status: Too Many Requests, body: {}")
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 3)
+}
+
+func (s *callAPISuite) TestNotRetry() {
+	s.setRetryPolicy()
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusBadRequest, "{}"), nil).Once()
+	for i := 0; i < 2; i++ {
+		mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+			Return(newResponse(http.StatusServiceUnavailable, "{}"), nil).Once()
+	}
+
+	// client errors are not retried
+	_, err := s.apiClient.CallAPI(context.Background(), "op1", nil, nil)
+	assert.Error(s.T(), err)
+	// calls changing resources are not retried
+	_, err = s.apiClient.CallAPI(context.Background(), "op2", nil, map[string]string{"test": "1"})
+	assert.Error(s.T(), err)
+	// create calls are not retried without check
+	_, err = s.apiClient.CallCreateAPI(context.Background(), "test-osss", nil,
+		map[string]string{"id": "1"}, nil)
+	assert.Error(s.T(), err)
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 3)
+}
+
+func (s *callAPISuite) TestRetryCreate() {
+	s.setRetryPolicy()
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusBadGateway, "{}"), nil).Once()
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusOK, `{"osss": {"id": 1}}`), nil).Once()
+
+	checked := 0
+	check := func(context.Context) ([]byte, error) {
+		checked++
+		return nil, nil
+	}
+	body, err := s.apiClient.CallCreateAPI(context.Background(), "test-osss", nil,
+		map[string]string{"id": "1"}, check)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), `{"osss": {"id": 1}}`, string(body))
+	assert.Equal(s.T(), 1, checked)
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 2)
+}
+
+func (s *callAPISuite) TestCreatedByFailedCall() {
+	s.setRetryPolicy()
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return((*http.Response)(nil), errors.New("EOF")).Once()
+
+	check := func(context.Context) ([]byte, error) {
+		return []byte(`{"osss": {"id": 2}}`), nil
+	}
+	body, err := s.apiClient.CallCreateAPI(context.Background(), "test-osss", nil,
+		map[string]string{"id": "1"}, check)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), `{"osss": {"id": 2}}`, string(body))
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 1)
+}
+
+func (s *callAPISuite) TestRetryDelay() {
+	policy := RetryPolicy{MaxRetries: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for retry, backoff := range []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+
+		delay := policy.delay(retry)
+		assert.True(s.T(), delay >= backoff/2 && delay <= backoff,
+			"delay %s of retry %d", delay, retry)
+	}
+	assert.Equal(s.T(), time.Duration(0), RetryPolicy{}.delay(1))
+}
+
+func (s *callAPISuite) TestReauthenticate() {
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	s.apiClient.SetToken("expired")
+	mockedClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Header.Get("Xms-Auth-Token") == "expired"
+	})).Return(newResponse(http.StatusUnauthorized, "{}"), nil).Once()
+	mockedClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Header.Get("Xms-Auth-Token") == "renewed"
+	})).Return(newResponse(http.StatusOK, "{}"), nil).Once()
+
+	provided := 0
+	s.apiClient.SetCredentialProvider(func(_ context.Context, authClient Client) (string, error) {
+		provided++
+		assert.Equal(s.T(), "", authClient.(*client).getToken())
+		return "renewed", nil
+	})
+	_, err := s.apiClient.CallAPI(context.Background(), "op2", nil, map[string]string{"test": "1"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, provided)
+	assert.Equal(s.T(), "renewed", s.apiClient.getToken())
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 2)
+}
+
+func (s *callAPISuite) TestReauthenticateOnce() {
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	s.apiClient.SetToken("expired")
+	for i := 0; i < 3; i++ {
+		mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+			Return(newResponse(http.StatusUnauthorized, "{}"), nil).Once()
+	}
+
+	// 401 is returned without credential provider
+	_, err := s.apiClient.CallAPI(context.Background(), "op1", nil, nil)
+	assert.EqualError(s.T(), err, "status: Unauthorized, body: {}")
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 1)
+
+	provided := 0
+	s.apiClient.SetCredentialProvider(func(context.Context, Client) (string, error) {
+		provided++
+		return "invalid", nil
+	})
+	_, err = s.apiClient.CallAPI(context.Background(), "op1", nil, nil)
+	assert.EqualError(s.T(), err, "status: Unauthorized, body: {}")
+	assert.Equal(s.T(), 1, provided)
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 3)
+}
+
+func (s *callAPISuite) TestRequestTimeout() {
+	s.setRetryPolicy()
+	s.apiClient.SetTimeout(time.Minute)
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	mockedClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		_, ok := req.Context().Deadline()
+		return ok
+	})).Return((*http.Response)(nil), context.DeadlineExceeded).Once()
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusOK, "{}"), nil).Once()
+
+	// request timed out is retried
+	_, err := s.apiClient.CallAPI(context.Background(), "op1", nil, nil)
+	assert.NoError(s.T(), err)
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 2)
+}
+
+func (s *callAPISuite) TestCanceled() {
+	s.setRetryPolicy()
+	s.apiClient.sleep = sleepWithContext
+	mockedClient := new(mockedHTTPClient)
+	s.apiClient.httpClient = mockedClient
+	mockedClient.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusServiceUnavailable, "{}"), nil).Once()
+
+	// call isn't retried once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.apiClient.CallAPI(ctx, "op1", nil, nil)
+	assert.EqualError(s.T(), err, "call op1: context canceled")
+	mockedClient.AssertNumberOfCalls(s.T(), "Do", 1)
+}
+
 func TestCallAPI(t *testing.T) {
 	suite.Run(t, new(callAPISuite))
 }
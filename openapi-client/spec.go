@@ -0,0 +1,165 @@
+package openapiclient
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// specIndexFile is the file in spec cache dir recording server version of each server
+const specIndexFile = "servers.json"
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func (c *client) SetSpecFile(path string) {
+	c.specFile = path
+}
+
+func (c *client) SetSpecCacheDir(dir string) {
+	c.specCacheDir = dir
+}
+
+// LoadSpec loads openapi spec from the spec file if it's set, otherwise the spec is got from
+// server and cached by its server version. Spec cached for the server is used if the server
+// doesn't serve the spec, e.g. docs of server are disabled.
+func (c *client) LoadSpec(ctx context.Context) error {
+	if c.specFile != "" {
+		bytes, err := ioutil.ReadFile(c.specFile)
+		if err != nil {
+			return errors.Annotate(err, "read openapi spec")
+		}
+		if err = c.ParseOpenAPISpec(bytes); err != nil {
+			return errors.Annotatef(err, "parse openapi spec %s", c.specFile)
+		}
+		return nil
+	}
+
+	bytes, err := c.getSpec(ctx)
+	if err == nil {
+		err = c.ParseOpenAPISpec(bytes)
+	}
+	if err != nil {
+		cachedErr := c.loadCachedSpec()
+		if cachedErr != nil {
+			log.Printf("failed to load cached openapi spec: %s", cachedErr)
+			return errors.Annotate(err, "get openapi spec")
+		}
+		log.Printf("cached openapi spec of %s is used since failed to get it from server: %s",
+			c.ServerVersion(), err)
+		return nil
+	}
+	if err = c.cacheSpec(bytes); err != nil {
+		log.Printf("failed to cache openapi spec: %s", err)
+	}
+	return nil
+}
+
+// getSpec gets openapi spec from server
+func (c *client) getSpec(ctx context.Context) ([]byte, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet,
+		strings.TrimSuffix(c.server, "/v1")+"/docs/openapi.json", nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var bytes []byte
+	if resp.Body != nil {
+		bytes, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, errors.Errorf("status: %s, body: %s", resp.Status, string(bytes))
+	}
+	return bytes, nil
+}
+
+func (c *client) specCachePath(serverVersion string) string {
+	fileName := unsafeFileNameChars.ReplaceAllString(serverVersion, "_") + ".json"
+	return filepath.Join(c.specCacheDir, fileName)
+}
+
+// readSpecIndex returns server versions of servers whose specs are cached
+func (c *client) readSpecIndex() (map[string]string, error) {
+	index := map[string]string{}
+	bytes, err := ioutil.ReadFile(filepath.Join(c.specCacheDir, specIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = json.Unmarshal(bytes, &index); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return index, nil
+}
+
+// cacheSpec saves the spec got from server as spec of its server version
+func (c *client) cacheSpec(bytes []byte) error {
+	if c.specCacheDir == "" {
+		return nil
+	}
+	// specs of servers without version can't be told apart
+	if c.ServerVersion() == "" {
+		return errors.Errorf("server version not found in openapi spec")
+	}
+	if err := os.MkdirAll(c.specCacheDir, 0755); err != nil {
+		return errors.Trace(err)
+	}
+	if err := ioutil.WriteFile(c.specCachePath(c.ServerVersion()), bytes, 0644); err != nil {
+		return errors.Trace(err)
+	}
+	index, err := c.readSpecIndex()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	index[c.server] = c.ServerVersion()
+	indexBytes, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(c.specCacheDir, specIndexFile), indexBytes, 0644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// loadCachedSpec loads spec cached for the server
+func (c *client) loadCachedSpec() error {
+	if c.specCacheDir == "" {
+		return errors.NotFoundf("spec cache dir")
+	}
+	index, err := c.readSpecIndex()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	serverVersion, ok := index[c.server]
+	if !ok || serverVersion == "" {
+		return errors.NotFoundf("cached spec of server %s", c.server)
+	}
+	bytes, err := ioutil.ReadFile(c.specCachePath(serverVersion))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = c.ParseOpenAPISpec(bytes); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
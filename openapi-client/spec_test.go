@@ -0,0 +1,100 @@
+package openapiclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const testSpec = `{
+	"openapi": "3.0.0",
+	"info": {"version": "SDS_4.2.009.0"},
+	"paths": {"/pools/": {"get": {"operationId": "ListPools"}}}
+}`
+
+type loadSpecSuite struct {
+	suite.Suite
+
+	dir       string
+	apiClient *client
+	mocked    *mockedHTTPClient
+}
+
+func (s *loadSpecSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "formation-spec")
+	s.Require().NoError(err)
+	s.dir = dir
+	s.mocked = new(mockedHTTPClient)
+	s.apiClient = new(client)
+	s.apiClient.httpClient = s.mocked
+	s.apiClient.SetServer("http://1.1.1.1/v1")
+	s.apiClient.SetSpecCacheDir(filepath.Join(dir, "openapi"))
+}
+
+func (s *loadSpecSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *loadSpecSuite) TestCacheSpec() {
+	s.mocked.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.String() == "http://1.1.1.1/docs/openapi.json"
+	})).Return(newResponse(http.StatusOK, testSpec), nil).Once()
+	s.NoError(s.apiClient.LoadSpec(context.Background()))
+	s.True(s.apiClient.HasOperation("ListPools"))
+	_, err := os.Stat(filepath.Join(s.dir, "openapi", "SDS_4.2.009.0.json"))
+	s.NoError(err)
+
+	// cached spec is used if docs of server are disabled
+	s.apiClient.openAPI = nil
+	s.mocked.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusNotFound, "{}"), nil).Once()
+	s.NoError(s.apiClient.LoadSpec(context.Background()))
+	s.Equal("SDS_4.2.009.0", s.apiClient.ServerVersion())
+	s.True(s.apiClient.HasOperation("ListPools"))
+
+	// spec of other server isn't used
+	s.apiClient.openAPI = nil
+	s.apiClient.SetServer("http://2.2.2.2/v1")
+	s.mocked.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusNotFound, "{}"), nil).Once()
+	err = s.apiClient.LoadSpec(context.Background())
+	s.EqualError(err, "get openapi spec: status: Not Found, body: {}")
+	_, err = s.apiClient.CallAPI(context.Background(), "ListPools", nil, nil)
+	s.EqualError(err, "openapi spec is not loaded")
+}
+
+func (s *loadSpecSuite) TestNotCacheSpecWithoutVersion() {
+	s.mocked.On("Do", mock.AnythingOfType("*http.Request")).
+		Return(newResponse(http.StatusOK, `{"paths": {}}`), nil).Once()
+	s.NoError(s.apiClient.LoadSpec(context.Background()))
+	s.Equal("", s.apiClient.ServerVersion())
+	_, err := os.Stat(filepath.Join(s.dir, "openapi"))
+	s.True(os.IsNotExist(err))
+}
+
+func (s *loadSpecSuite) TestSpecFile() {
+	specFile := filepath.Join(s.dir, "spec.json")
+	s.apiClient.SetSpecFile(specFile)
+	s.Error(s.apiClient.LoadSpec(context.Background()))
+
+	s.NoError(ioutil.WriteFile(specFile, []byte(`{"openapi": "3.0.0"}`), 0644))
+	s.EqualError(s.apiClient.LoadSpec(context.Background()),
+		"parse openapi spec "+specFile+": paths not found in openapi spec")
+
+	s.NoError(ioutil.WriteFile(specFile, []byte(testSpec), 0644))
+	s.NoError(s.apiClient.LoadSpec(context.Background()))
+	s.True(s.apiClient.HasOperation("ListPools"))
+	s.False(s.apiClient.HasOperation("CreatePool"))
+	// spec is not got from server
+	s.mocked.AssertNotCalled(s.T(), "Do", mock.Anything)
+}
+
+func TestLoadSpec(t *testing.T) {
+	suite.Run(t, new(loadSpecSuite))
+}
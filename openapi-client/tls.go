@@ -0,0 +1,58 @@
+package openapiclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+
+	"github.com/juju/errors"
+)
+
+// TLSOptions defines options of TLS connections to the server
+type TLSOptions struct {
+	// CAFile path of PEM encoded CA certificates trusted besides system CAs
+	CAFile string
+	// CertFile and KeyFile paths of PEM encoded client certificate and its private key
+	CertFile string
+	KeyFile  string
+	// ServerName overrides name of the server used to verify its certificate
+	ServerName string
+	// InsecureSkipVerify disables verification of the server certificate
+	InsecureSkipVerify bool
+}
+
+// tlsConfig returns TLS config with the options, nil is returned if no options are set
+func (o *TLSOptions) tlsConfig() (*tls.Config, error) {
+	if o == nil || *o == (TLSOptions{}) {
+		return nil, nil
+	}
+	config := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		data, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, errors.Annotate(err, "read CA file")
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("no certificates found in CA file %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.Errorf("both client certificate and key files are required")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, errors.Annotate(err, "load client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,84 @@
+package openapiclient
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type tlsSuite struct {
+	suite.Suite
+
+	dir    string
+	server *httptest.Server
+	caFile string
+}
+
+func (s *tlsSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "formation-tls")
+	s.Require().NoError(err)
+	s.dir = dir
+	s.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	s.caFile = filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.server.Certificate().Raw})
+	s.Require().NoError(ioutil.WriteFile(s.caFile, ca, 0600))
+}
+
+func (s *tlsSuite) TearDownTest() {
+	s.server.Close()
+	os.RemoveAll(s.dir)
+}
+
+func (s *tlsSuite) get(options *TLSOptions) error {
+	c := NewOpenAPIClient(options).(*client)
+	if err := c.Init(); err != nil {
+		return err
+	}
+	resp, err := c.Get(s.server.URL)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *tlsSuite) TestVerifyServer() {
+	// certificate of test server isn't trusted by system
+	assert.Error(s.T(), s.get(nil))
+	assert.NoError(s.T(), s.get(&TLSOptions{CAFile: s.caFile}))
+	// certificate of test server is for example.com
+	assert.NoError(s.T(), s.get(&TLSOptions{CAFile: s.caFile, ServerName: "example.com"}))
+	assert.Error(s.T(), s.get(&TLSOptions{CAFile: s.caFile, ServerName: "xsky.com"}))
+	assert.NoError(s.T(), s.get(&TLSOptions{InsecureSkipVerify: true}))
+}
+
+func (s *tlsSuite) TestInvalidOptions() {
+	invalidFile := filepath.Join(s.dir, "invalid.pem")
+	s.Require().NoError(ioutil.WriteFile(invalidFile, []byte("invalid"), 0600))
+
+	_, err := (&TLSOptions{CAFile: invalidFile}).tlsConfig()
+	assert.EqualError(s.T(), err, "no certificates found in CA file "+invalidFile)
+	_, err = (&TLSOptions{CAFile: filepath.Join(s.dir, "none.pem")}).tlsConfig()
+	assert.Error(s.T(), err)
+	_, err = (&TLSOptions{CertFile: s.caFile}).tlsConfig()
+	assert.EqualError(s.T(), err, "both client certificate and key files are required")
+	_, err = (&TLSOptions{CertFile: s.caFile, KeyFile: invalidFile}).tlsConfig()
+	assert.Error(s.T(), err)
+
+	config, err := (&TLSOptions{}).tlsConfig()
+	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), config)
+}
+
+func TestTLS(t *testing.T) {
+	suite.Run(t, new(tlsSuite))
+}
@@ -0,0 +1,99 @@
+package formation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/parser"
+)
+
+// Output defines a value exported by the stack after resources are created
+type Output struct {
+	Description string          `json:",omitempty"`
+	Type        string          `json:",omitempty"` // String,StringList,Integer,IntegerList,Bool,BoolList
+	Value       parser.ExprType `json:",omitempty"`
+}
+
+// UnmarshalJSON sets the output from the provided JSON
+func (o *Output) UnmarshalJSON(buf []byte) (err error) {
+	m := map[string]json.RawMessage{}
+	if err = json.Unmarshal(buf, &m); err != nil {
+		return errors.Trace(err)
+	}
+
+	descriptionBytes, ok := m["Description"]
+	if ok {
+		if err = json.Unmarshal(descriptionBytes, &o.Description); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	typeBytes, ok := m["Type"]
+	if !ok {
+		return errors.Errorf("Type is required for output")
+	}
+	if err = json.Unmarshal(typeBytes, &o.Type); err != nil {
+		return errors.Trace(err)
+	}
+
+	valueBytes, ok := m["Value"]
+	if !ok {
+		return errors.Errorf("Value is required for output")
+	}
+	if o.Value = parser.NewExpr(o.Type); o.Value == nil {
+		return errors.Errorf("got invalid output type %s", o.Type)
+	}
+	if err = json.Unmarshal(valueBytes, o.Value); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// GetOutputs returns values of outputs defined in the template
+func (s *Stack) GetOutputs() (map[string]interface{}, error) {
+	outputs := make(map[string]interface{}, len(s.template.Outputs))
+	for name, output := range s.template.Outputs {
+		value, err := parser.GetExprValue(s, output.Value)
+		if err != nil {
+			return nil, errors.Annotatef(err, "get value of output %s", name)
+		}
+		outputs[name] = value
+	}
+	return outputs, nil
+}
+
+func printOutputs(w io.Writer, outputs map[string]interface{}) {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "Outputs:")
+	for _, name := range names {
+		fmt.Fprintf(w, "    %s = %v\n", name, outputs[name])
+	}
+}
+
+func writeOutputs(path string, outputs map[string]interface{}) error {
+	outputData, err := json.MarshalIndent(outputs, "", "    ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	outputFile, err := OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = outputFile.Write(append(outputData, '\n')); err != nil {
+		outputFile.Close()
+		return errors.Trace(err)
+	}
+	if err = outputFile.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
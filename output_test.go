@@ -0,0 +1,77 @@
+package formation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type outputSuite struct {
+	suite.Suite
+
+	stack *Stack
+}
+
+func (s *outputSuite) SetupTest() {
+	s.stack = new(Stack)
+	s.stack.template = new(Template)
+	s.stack.resourceValueMap = map[string]interface{}{
+		"pool":    int64(1),
+		"volumes": []int64{2, 3},
+	}
+}
+
+func (s *outputSuite) TestGetOutputs() {
+	s.NoError(json.Unmarshal([]byte(`{
+		"Outputs": {
+			"PoolID": {"Description": "id of pool", "Type": "Integer", "Value": {"Ref": "pool"}},
+			"VolumeID": {"Type": "Integer", "Value": {"Select": [1, {"Ref": "volumes"}]}},
+			"Name": {"Type": "String", "Value": "test"}
+		}
+	}`), s.stack.template))
+
+	outputs, err := s.stack.GetOutputs()
+	s.NoError(err)
+	s.Equal(map[string]interface{}{"PoolID": int64(1), "VolumeID": int64(3), "Name": "test"}, outputs)
+
+	var out bytes.Buffer
+	printOutputs(&out, outputs)
+	s.Equal("Outputs:\n    Name = test\n    PoolID = 1\n    VolumeID = 3\n", out.String())
+}
+
+func (s *outputSuite) TestGetOutputsWithError() {
+	s.Error(json.Unmarshal([]byte(`{"Outputs": {"PoolID": {"Value": {"Ref": "pool"}}}}`),
+		new(Template)))
+
+	s.NoError(json.Unmarshal([]byte(`{
+		"Outputs": {"Gateway": {"Type": "String", "Value": {"Ref": "gateway"}}}
+	}`), s.stack.template))
+	_, err := s.stack.GetOutputs()
+	s.Error(err)
+}
+
+func (s *outputSuite) TestWriteOutputs() {
+	oldOpenFile := OpenFile
+	OpenFile = realOpenFile
+	defer func() {
+		OpenFile = oldOpenFile
+	}()
+	dir, err := ioutil.TempDir("", "formation-output")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "outputs.json")
+	s.NoError(writeOutputs(path, map[string]interface{}{"PoolID": int64(1)}))
+	data, err := ioutil.ReadFile(path)
+	s.NoError(err)
+	s.JSONEq(`{"PoolID": 1}`, string(data))
+}
+
+func TestOutputSuite(t *testing.T) {
+	suite.Run(t, new(outputSuite))
+}
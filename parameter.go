@@ -0,0 +1,369 @@
+package formation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
+
+// ParamEnvPrefix is prefix of environment variables overriding parameters of template
+const ParamEnvPrefix = "FORMATION_PARAM_"
+
+// setJSONValue sets value of parameter from json, value of string is parsed as the
+// value from command line
+func (p *Parameter) setJSONValue(data []byte) (err error) {
+	var str string
+	if p.Type != "String" && strings.HasPrefix(string(data), `"`) {
+		if err = json.Unmarshal(data, &str); err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(p.SetStringValue(str))
+	}
+	switch p.Type {
+	case "Integer":
+		var integer int64
+		err = json.Unmarshal(data, &integer)
+		p.Value = integer
+	case "String":
+		err = json.Unmarshal(data, &str)
+		p.Value = str
+	case "IntegerList":
+		integerList := []int64{}
+		err = json.Unmarshal(data, &integerList)
+		p.Value = integerList
+	case "StringList":
+		strList := []string{}
+		err = json.Unmarshal(data, &strList)
+		p.Value = strList
+	case "Bool":
+		var boolean bool
+		err = json.Unmarshal(data, &boolean)
+		p.Value = boolean
+	case "BoolList":
+		boolList := []bool{}
+		err = json.Unmarshal(data, &boolList)
+		p.Value = boolList
+	default:
+		return errors.Errorf("unknown parameter type %s", p.Type)
+	}
+	if err != nil {
+		return errors.Annotatef(err, "parse %s of type %s", p.displayValue(string(data)), p.Type)
+	}
+	return nil
+}
+
+// SetStringValue sets value of parameter from string, items of list are separated by comma
+// or set as json array
+func (p *Parameter) SetStringValue(value string) error {
+	if strings.HasPrefix(strings.TrimSpace(value), "[") &&
+		(p.Type == "IntegerList" || p.Type == "StringList" || p.Type == "BoolList") {
+
+		return errors.Trace(p.setJSONValue([]byte(value)))
+	}
+	var items []string
+	if value = strings.TrimSpace(value); value != "" {
+		items = strings.Split(value, ",")
+	}
+	switch p.Type {
+	case "Integer":
+		integer, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.Errorf("%q is not a valid Integer", p.displayValue(value))
+		}
+		p.Value = integer
+	case "String":
+		p.Value = value
+	case "IntegerList":
+		integerList := make([]int64, 0, len(items))
+		for _, item := range items {
+			integer, err := strconv.ParseInt(strings.TrimSpace(item), 10, 64)
+			if err != nil {
+				return errors.Errorf("%q is not a valid IntegerList", p.displayValue(value))
+			}
+			integerList = append(integerList, integer)
+		}
+		p.Value = integerList
+	case "StringList":
+		strList := make([]string, 0, len(items))
+		for _, item := range items {
+			strList = append(strList, strings.TrimSpace(item))
+		}
+		p.Value = strList
+	case "Bool":
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.Errorf("%q is not a valid Bool", p.displayValue(value))
+		}
+		p.Value = boolean
+	case "BoolList":
+		boolList := make([]bool, 0, len(items))
+		for _, item := range items {
+			boolean, err := strconv.ParseBool(strings.TrimSpace(item))
+			if err != nil {
+				return errors.Errorf("%q is not a valid BoolList", p.displayValue(value))
+			}
+			boolList = append(boolList, boolean)
+		}
+		p.Value = boolList
+	default:
+		return errors.Errorf("unknown parameter type %s", p.Type)
+	}
+	return nil
+}
+
+func (p *Parameter) unmarshalAttributes(buf []byte) error {
+	constraints := struct {
+		Description           string
+		NoEcho                bool
+		AllowedValues         []json.RawMessage
+		MinValue              *int64
+		MaxValue              *int64
+		AllowedPattern        string
+		MinLength             *int
+		MaxLength             *int
+		ConstraintDescription string
+	}{}
+	if err := json.Unmarshal(buf, &constraints); err != nil {
+		return errors.Annotatef(err, "parse attributes of parameter")
+	}
+	p.Description, p.NoEcho = constraints.Description, constraints.NoEcho
+	p.MinValue, p.MaxValue = constraints.MinValue, constraints.MaxValue
+	p.AllowedPattern = constraints.AllowedPattern
+	p.MinLength, p.MaxLength = constraints.MinLength, constraints.MaxLength
+	p.ConstraintDescription = constraints.ConstraintDescription
+	if p.AllowedPattern != "" {
+		if _, err := regexp.Compile(p.AllowedPattern); err != nil {
+			return errors.Annotatef(err, "invalid AllowedPattern %s", p.AllowedPattern)
+		}
+	}
+
+	p.AllowedValues = nil
+	for _, data := range constraints.AllowedValues {
+		var value interface{}
+		var err error
+		switch p.Type {
+		case "Integer", "IntegerList":
+			var integer int64
+			err = json.Unmarshal(data, &integer)
+			value = integer
+		case "Bool", "BoolList":
+			var boolean bool
+			err = json.Unmarshal(data, &boolean)
+			value = boolean
+		default:
+			var str string
+			err = json.Unmarshal(data, &str)
+			value = str
+		}
+		if err != nil {
+			return errors.Annotatef(err, "parse AllowedValues of parameter of type %s", p.Type)
+		}
+		p.AllowedValues = append(p.AllowedValues, value)
+	}
+	return nil
+}
+
+// AddSecrets registers value of parameter as secret if it's NoEcho, only values of String
+// and StringList are registered since numbers are too common in logs
+func (p *Parameter) AddSecrets() {
+	if !p.NoEcho {
+		return
+	}
+	switch value := p.Value.(type) {
+	case string:
+		utils.AddSecret(value)
+	case []string:
+		for _, item := range value {
+			utils.AddSecret(item)
+		}
+	}
+}
+
+// displayValue returns value shown in messages, value of NoEcho parameter is redacted since
+// it's not registered as secret until it's validated
+func (p *Parameter) displayValue(value interface{}) interface{} {
+	if p.NoEcho {
+		return utils.RedactedValue
+	}
+	return value
+}
+
+// Validate checks whether value of parameter satisfies its constraints
+func (p *Parameter) Validate() error {
+	var items []interface{}
+	length := -1
+	switch value := p.Value.(type) {
+	case int64:
+		items = []interface{}{value}
+	case string:
+		items = []interface{}{value}
+		length = len(value)
+	case []int64:
+		for _, item := range value {
+			items = append(items, item)
+		}
+		length = len(value)
+	case []string:
+		for _, item := range value {
+			items = append(items, item)
+		}
+		length = len(value)
+	case bool:
+		items = []interface{}{value}
+	case []bool:
+		for _, item := range value {
+			items = append(items, item)
+		}
+		length = len(value)
+	}
+
+	if length >= 0 && p.MinLength != nil && length < *p.MinLength {
+		return p.constraintError("length %d is less than %d", length, *p.MinLength)
+	}
+	if length >= 0 && p.MaxLength != nil && length > *p.MaxLength {
+		return p.constraintError("length %d is greater than %d", length, *p.MaxLength)
+	}
+	for _, item := range items {
+		if err := p.validateItem(item); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (p *Parameter) validateItem(item interface{}) error {
+	if len(p.AllowedValues) != 0 {
+		allowed := false
+		for _, allowedValue := range p.AllowedValues {
+			if item == allowedValue {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			// allowed values of NoEcho parameter are secrets too
+			if p.NoEcho {
+				return p.constraintError("%v is not one of allowed values", utils.RedactedValue)
+			}
+			return p.constraintError("%v is not one of %v", item, p.AllowedValues)
+		}
+	}
+	switch value := item.(type) {
+	case int64:
+		if p.MinValue != nil && value < *p.MinValue {
+			return p.constraintError("%v is less than %d", p.displayValue(value), *p.MinValue)
+		}
+		if p.MaxValue != nil && value > *p.MaxValue {
+			return p.constraintError("%v is greater than %d", p.displayValue(value), *p.MaxValue)
+		}
+	case string:
+		if p.AllowedPattern != "" {
+			// pattern should match the whole value
+			matched, err := regexp.MatchString("^(?:"+p.AllowedPattern+")$", value)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if !matched {
+				return p.constraintError("%q doesn't match pattern %s", p.displayValue(value),
+					p.AllowedPattern)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Parameter) constraintError(format string, args ...interface{}) error {
+	if p.ConstraintDescription != "" {
+		return errors.New(p.ConstraintDescription)
+	}
+	return errors.New(fmt.Sprintf(format, args...))
+}
+
+// ValidateParameters checks values of all parameters, errors of all invalid parameters
+// are returned together
+func ValidateParameters(parameters map[string]*Parameter) error {
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var messages []string
+	for _, name := range names {
+		param := parameters[name]
+		if err := param.Validate(); err != nil {
+			messages = append(messages, fmt.Sprintf("invalid value %v of parameter %s: %s",
+				param.displayValue(param.Value), name, err))
+		}
+	}
+	if len(messages) != 0 {
+		return errors.New(strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// OverrideParameters overrides values of parameters with values in the parameter file,
+// environment variables and command line in order
+func OverrideParameters(parameters map[string]*Parameter, paramFile string,
+	cmdParams map[string]string) error {
+
+	if paramFile != "" {
+		fileParams, err := loadParamFile(paramFile)
+		if err != nil {
+			return errors.Annotatef(err, "load parameter file %s", paramFile)
+		}
+		for name, value := range fileParams {
+			param, ok := parameters[name]
+			if !ok {
+				return errors.NotFoundf("parameter %s in file %s", name, paramFile)
+			}
+			if err = param.setJSONValue(value); err != nil {
+				return errors.Annotatef(err, "parameter %s in file %s", name, paramFile)
+			}
+		}
+	}
+
+	for name, param := range parameters {
+		value, ok := os.LookupEnv(ParamEnvPrefix + name)
+		if !ok {
+			value, ok = os.LookupEnv(ParamEnvPrefix + strings.ToUpper(name))
+		}
+		if !ok {
+			continue
+		}
+		if err := param.SetStringValue(value); err != nil {
+			return errors.Annotatef(err, "parameter %s in environment variable", name)
+		}
+	}
+
+	for name, value := range cmdParams {
+		param, ok := parameters[name]
+		if !ok {
+			return errors.NotFoundf("parameter %s in command line", name)
+		}
+		if err := param.SetStringValue(value); err != nil {
+			return errors.Annotatef(err, "parameter %s in command line", name)
+		}
+	}
+	return nil
+}
+
+func loadParamFile(path string) (map[string]json.RawMessage, error) {
+	file, err := OpenFile(path, os.O_RDONLY)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer file.Close()
+	params := map[string]json.RawMessage{}
+	if err = json.NewDecoder(file).Decode(&params); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return params, nil
+}
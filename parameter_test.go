@@ -0,0 +1,190 @@
+package formation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/suite"
+
+	"xsky.com/sds-formation/utils"
+)
+
+type parameterSuite struct {
+	suite.Suite
+
+	parameters  map[string]*Parameter
+	oldOpenFile OpenFileFunc
+}
+
+func (s *parameterSuite) SetupTest() {
+	s.oldOpenFile = OpenFile
+	OpenFile = realOpenFile
+	s.parameters = map[string]*Parameter{}
+	s.NoError(json.Unmarshal([]byte(`{
+		"ClusterURL": {"Type": "String", "Value": "http://10.0.0.1:8056/v1"},
+		"pool_size": {"Type": "Integer", "Value": 1},
+		"host_ids": {"Type": "IntegerList", "Value": [1, 2]},
+		"admin_ips": {"Type": "StringList"},
+		"compress": {"Type": "Bool", "Value": "true"},
+		"crypto": {"Type": "BoolList", "Value": [true, false]}
+	}`), &s.parameters))
+	s.Equal(true, s.parameters["compress"].Value)
+	s.Equal([]bool{true, false}, s.parameters["crypto"].Value)
+}
+
+func (s *parameterSuite) TearDownTest() {
+	OpenFile = s.oldOpenFile
+}
+
+func (s *parameterSuite) TestSetStringValue() {
+	s.NoError(s.parameters["pool_size"].SetStringValue("3"))
+	s.Equal(int64(3), s.parameters["pool_size"].Value)
+	s.NoError(s.parameters["host_ids"].SetStringValue("3, 4"))
+	s.Equal([]int64{3, 4}, s.parameters["host_ids"].Value)
+	s.NoError(s.parameters["host_ids"].SetStringValue("[5]"))
+	s.Equal([]int64{5}, s.parameters["host_ids"].Value)
+	s.NoError(s.parameters["admin_ips"].SetStringValue("10.0.0.1,10.0.0.2"))
+	s.Equal([]string{"10.0.0.1", "10.0.0.2"}, s.parameters["admin_ips"].Value)
+	s.NoError(s.parameters["admin_ips"].SetStringValue(""))
+	s.Equal([]string{}, s.parameters["admin_ips"].Value)
+
+	s.EqualError(s.parameters["pool_size"].SetStringValue("large"), `"large" is not a valid Integer`)
+	s.EqualError(s.parameters["host_ids"].SetStringValue("1,a"), `"1,a" is not a valid IntegerList`)
+
+	s.NoError(s.parameters["compress"].SetStringValue("false"))
+	s.Equal(false, s.parameters["compress"].Value)
+	s.NoError(s.parameters["crypto"].SetStringValue("true, 0"))
+	s.Equal([]bool{true, false}, s.parameters["crypto"].Value)
+	s.NoError(s.parameters["crypto"].SetStringValue("[false]"))
+	s.Equal([]bool{false}, s.parameters["crypto"].Value)
+	s.EqualError(s.parameters["compress"].SetStringValue("yes"), `"yes" is not a valid Bool`)
+	s.EqualError(s.parameters["crypto"].SetStringValue("true,no"), `"true,no" is not a valid BoolList`)
+}
+
+func (s *parameterSuite) TestOverrideParameters() {
+	dir, err := ioutil.TempDir("", "formation-param")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	paramFile := filepath.Join(dir, "params.json")
+	s.NoError(ioutil.WriteFile(paramFile,
+		[]byte(`{"pool_size": 2, "host_ids": "7,8", "admin_ips": ["10.0.0.1"]}`), 0644))
+	os.Setenv("FORMATION_PARAM_POOL_SIZE", "4")
+	defer os.Unsetenv("FORMATION_PARAM_POOL_SIZE")
+
+	err = OverrideParameters(s.parameters, paramFile,
+		map[string]string{"ClusterURL": "http://10.0.0.2:8056/v1"})
+	s.NoError(err)
+	s.Equal("http://10.0.0.2:8056/v1", s.parameters["ClusterURL"].Value)
+	s.Equal(int64(4), s.parameters["pool_size"].Value)
+	s.Equal([]int64{7, 8}, s.parameters["host_ids"].Value)
+	s.Equal([]string{"10.0.0.1"}, s.parameters["admin_ips"].Value)
+
+	err = OverrideParameters(s.parameters, "", map[string]string{"pool_size": "1.5"})
+	s.Error(err)
+	err = OverrideParameters(s.parameters, "", map[string]string{"volume_size": "1"})
+	s.True(errors.IsNotFound(err))
+}
+
+func (s *parameterSuite) TestValidateParameters() {
+	parameters := map[string]*Parameter{}
+	s.NoError(json.Unmarshal([]byte(`{
+		"pool_size": {"Type": "Integer", "Value": 3, "MinValue": 1, "MaxValue": 3},
+		"pool_type": {"Type": "String", "Value": "replicated",
+			"AllowedValues": ["replicated", "erasure"]},
+		"admin_ips": {"Type": "StringList", "Value": ["10.0.0.1"],
+			"AllowedPattern": "\\d+\\.\\d+\\.\\d+\\.\\d+", "MinLength": 1,
+			"ConstraintDescription": "at least one IPv4 address is required"},
+		"name": {"Type": "String", "Value": "pool", "Description": "name of pool", "MaxLength": 4}
+	}`), &parameters))
+	s.Equal("name of pool", parameters["name"].Description)
+	s.NoError(ValidateParameters(parameters))
+
+	parameters["pool_size"].Value = int64(4)
+	s.EqualError(ValidateParameters(parameters), "invalid value 4 of parameter pool_size: 4 is greater than 3")
+
+	parameters["pool_size"].Value = int64(1)
+	parameters["pool_type"].Value = "ec"
+	parameters["admin_ips"].Value = []string{"10.0.0.1", "host1"}
+	parameters["name"].Value = "pool1"
+	s.EqualError(ValidateParameters(parameters),
+		"invalid value [10.0.0.1 host1] of parameter admin_ips: at least one IPv4 address is required; "+
+			"invalid value pool1 of parameter name: length 5 is greater than 4; "+
+			"invalid value ec of parameter pool_type: ec is not one of [replicated erasure]")
+
+	s.Error(json.Unmarshal([]byte(`{"Type": "String", "AllowedPattern": "("}`), new(Parameter)))
+	s.Error(json.Unmarshal([]byte(`{"Type": "Integer", "AllowedValues": ["a"]}`), new(Parameter)))
+}
+
+func (s *parameterSuite) TestNoEcho() {
+	parameters := map[string]*Parameter{}
+	s.NoError(json.Unmarshal([]byte(`{
+		"password": {"Type": "String", "Value": "p@ss<word>", "NoEcho": true},
+		"name": {"Type": "String", "Value": "admin"}
+	}`), &parameters))
+	for _, param := range parameters {
+		param.AddSecrets()
+	}
+
+	s.Equal("login admin with ******", utils.Redact("login admin with p@ss<word>"))
+	body, err := json.Marshal(map[string]string{"password": "p@ss<word>"})
+	s.NoError(err)
+	s.Equal(`{"password":"******"}`, utils.Redact(string(body)))
+
+	var out bytes.Buffer
+	log.New(utils.NewRedactWriter(&out), "", 0).Printf("token resource with password p@ss<word>")
+	s.Equal("token resource with password ******\n", out.String())
+}
+
+func (s *parameterSuite) TestNoEchoInvalidValue() {
+	parameters := map[string]*Parameter{}
+	s.NoError(json.Unmarshal([]byte(`{
+		"password": {"Type": "String", "Value": "short", "NoEcho": true, "MinLength": 8,
+			"AllowedPattern": "[a-z]+[0-9]+"},
+		"pin": {"Type": "Integer", "Value": 1234, "NoEcho": true, "MinValue": 100000}
+	}`), &parameters))
+
+	err := ValidateParameters(parameters)
+	s.EqualError(err, "invalid value ****** of parameter password: length 5 is less than 8; "+
+		"invalid value ****** of parameter pin: ****** is less than 100000")
+	parameters["password"].MinLength = nil
+	err = ValidateParameters(parameters)
+	s.Contains(err.Error(), `"******" doesn't match pattern`)
+	s.NotContains(err.Error(), "short")
+
+	err = OverrideParameters(parameters, "", map[string]string{"pin": "secret-pin"})
+	s.EqualError(err, `parameter pin in command line: "******" is not a valid Integer`)
+
+	err = json.Unmarshal([]byte(`{"pin": {"Type": "Integer", "Value": "secret-pin", "NoEcho": true}}`),
+		&parameters)
+	s.Error(err)
+	s.NotContains(err.Error(), "secret-pin")
+
+	s.NoError(json.Unmarshal([]byte(`{"code": {"Type": "String", "Value": "guess", "NoEcho": true,
+		"AllowedValues": ["secret-a", "secret-b"]}}`), &parameters))
+	err = ValidateParameters(map[string]*Parameter{"code": parameters["code"]})
+	s.EqualError(err, "invalid value ****** of parameter code: ****** is not one of allowed values")
+}
+
+func (s *parameterSuite) TestResolveSensitiveProperties() {
+	stack := new(Stack)
+	stack.resourceValueMap = map[string]interface{}{"pwd": "secret-of-token"}
+	var token ResourceInTemplate
+	s.NoError(json.Unmarshal([]byte(`{"Name": "token", "Type": "Token",
+		"Properties": {"Name": "admin", "Password": {"Ref": "pwd"}}}`), &token))
+	token.Properties.Init(stack)
+
+	properties, err := token.Properties.ResolveProperties()
+	s.NoError(err)
+	s.Equal(map[string]interface{}{"Name": "admin", "Password": utils.RedactedValue}, properties)
+	s.Equal("password ******", utils.Redact("password secret-of-token"))
+}
+
+func TestParameterSuite(t *testing.T) {
+	suite.Run(t, new(parameterSuite))
+}
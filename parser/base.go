@@ -1,12 +1,19 @@
 package parser
 
-import "xsky.com/sds-formation/utils"
+import (
+	"reflect"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
 
 // ExprType defines expression interface
 type ExprType interface {
 	GetType() string
 	GetDeclaration() string
 	IsReady(utils.StackInterface) bool
+	References() []string
 }
 
 // ListExprType defines list expression interface
@@ -24,6 +31,14 @@ func (expr *baseExpr) GetDeclaration() string {
 	return expr.declaration
 }
 
+// References returns names of parameters and resources referenced by the function of expression
+func (expr *baseExpr) References() []string {
+	if expr.Func != nil {
+		return expr.Func.references()
+	}
+	return nil
+}
+
 // types of expression
 const (
 	ValueTypeBool        = "Bool"
@@ -33,3 +48,115 @@ const (
 	ValueTypeString      = "String"
 	ValueTypeStringList  = "StringList"
 )
+
+// NewExpr returns an empty expression of the value type, nil is returned for unknown type
+func NewExpr(valueType string) ExprType {
+	switch valueType {
+	case ValueTypeBool:
+		return new(BoolExpr)
+	case ValueTypeBoolList:
+		return new(BoolListExpr)
+	case ValueTypeInteger:
+		return new(IntegerExpr)
+	case ValueTypeIntegerList:
+		return new(IntegerListExpr)
+	case ValueTypeString:
+		return new(StringExpr)
+	case ValueTypeStringList:
+		return new(StringListExpr)
+	}
+	return nil
+}
+
+// GetExprValue returns value of an expression
+func GetExprValue(stack utils.StackInterface, expr ExprType) (value interface{}, err error) {
+	switch e := expr.(type) {
+	case *BoolExpr:
+		value, err = e.GetValue(stack)
+	case *BoolListExpr:
+		value, err = e.GetValue(stack)
+	case *IntegerExpr:
+		value, err = e.GetValue(stack)
+	case *IntegerListExpr:
+		value, err = e.GetValue(stack)
+	case *StringExpr:
+		value, err = e.GetValue(stack)
+	case *StringListExpr:
+		value, err = e.GetValue(stack)
+	default:
+		return nil, errors.Errorf("unsupported expression type %s", expr.GetType())
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return value, nil
+}
+
+// TypedReference is a parameter or resource referenced by Ref function, ValueType is type of
+// value expected by the expression and is empty if value of any type is accepted
+type TypedReference struct {
+	Name      string
+	ValueType string
+}
+
+var funcType = reflect.TypeOf((*Func)(nil)).Elem()
+
+// visitFuncs calls visit with every function in the value, which could be an expression or
+// a struct with expressions, e.g. properties of a resource
+func visitFuncs(value interface{}, visit func(f Func)) {
+	var walk func(val reflect.Value)
+	walk = func(val reflect.Value) {
+		switch val.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if val.IsNil() {
+				return
+			}
+			if val.Kind() == reflect.Ptr && val.Type().Implements(funcType) {
+				visit(val.Interface().(Func))
+			}
+			walk(val.Elem())
+		case reflect.Struct:
+			for i := 0; i < val.NumField(); i++ {
+				// private fields are skipped except embedded ones, e.g. baseExpr
+				field := val.Type().Field(i)
+				if field.PkgPath == "" || field.Anonymous {
+					walk(val.Field(i))
+				}
+			}
+		case reflect.Slice:
+			for i := 0; i < val.Len(); i++ {
+				walk(val.Index(i))
+			}
+		case reflect.Map:
+			for _, key := range val.MapKeys() {
+				walk(val.MapIndex(key))
+			}
+		}
+	}
+	walk(reflect.ValueOf(value))
+}
+
+// TypedReferences returns parameters and resources referenced by Ref functions in the value,
+// which could be an expression or a struct with expressions, e.g. properties of a resource
+func TypedReferences(value interface{}) (refs []TypedReference) {
+	visitFuncs(value, func(f Func) {
+		if refFunc, ok := f.(*RefFunc); ok {
+			refs = append(refs, TypedReference{Name: refFunc.Ref, ValueType: refFunc.valueType})
+		}
+	})
+	return refs
+}
+
+// ConditionReferences returns names of conditions referenced by Condition and If functions
+// in the value
+func ConditionReferences(value interface{}) (names []string) {
+	visitFuncs(value, func(f Func) {
+		switch fn := f.(type) {
+		case *ConditionFunc:
+			names = append(names, fn.Condition)
+		case *IfFunc:
+			names = append(names, fn.Condition)
+		}
+	})
+	return names
+}
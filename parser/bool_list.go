@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"encoding/json"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
+
+// BoolListExpr is a bool list expression
+type BoolListExpr struct {
+	baseExpr
+	Literal []*BoolExpr
+}
+
+// GetType returns type of bool list expression
+func (expr *BoolListExpr) GetType() string {
+	return ValueTypeBoolList
+}
+
+// IsReady returns if bool list expression is ready
+func (expr *BoolListExpr) IsReady(stack utils.StackInterface) (ready bool) {
+	if expr.Func != nil {
+		return expr.Func.isReady(stack)
+	}
+	for _, subExpr := range expr.Literal {
+		if !subExpr.IsReady(stack) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetValue returns value of bool list expression
+func (expr *BoolListExpr) GetValue(stack utils.StackInterface) (value []bool, err error) {
+	if expr.Func != nil {
+		fValue, err := expr.Func.getValue(stack)
+		if err != nil {
+			return value, errors.Trace(err)
+		}
+
+		var ok bool
+		if value, ok = fValue.([]bool); !ok {
+			var item bool
+			if item, ok = fValue.(bool); !ok {
+				return value, ExpressionValueInvalidError{
+					Declaration: expr.GetDeclaration(), Type: expr.GetType()}
+			}
+			return []bool{item}, nil
+		}
+		return value, nil
+	}
+
+	// expr with serveral sub expr
+	for _, subExpr := range expr.Literal {
+		subValue, err := subExpr.GetValue(stack)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		value = append(value, subValue)
+	}
+	return value, nil
+}
+
+// References returns names of parameters and resources referenced by bool list expression
+func (expr *BoolListExpr) References() (names []string) {
+	if expr.Func != nil {
+		return expr.Func.references()
+	}
+	for _, subExpr := range expr.Literal {
+		names = append(names, subExpr.References()...)
+	}
+	return names
+}
+
+// Select returns value of list expression by index
+func (expr *BoolListExpr) Select(stack utils.StackInterface, index int) (interface{}, error) {
+	value, err := expr.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if index >= len(value) || index < 0 {
+		return nil, errors.Errorf("invalid index %d for value of %s", index, expr.GetDeclaration())
+	}
+	return value[index], nil
+}
+
+// UnmarshalJSON sets the object from the provided JSON representation
+func (expr *BoolListExpr) UnmarshalJSON(data []byte) error {
+	var v []*BoolExpr
+	err := json.Unmarshal(data, &v)
+	if err == nil {
+		expr.Func = nil
+		expr.Literal = v
+		return nil
+	}
+
+	// Perhaps we have a serialized function call (like `{"Ref": "Foo"}`)
+	// so we'll try to unmarshal it with UnmarshalFunc.
+	funcCall, err2 := unmarshalFunc(expr.GetType(), data)
+	if err2 == nil {
+		expr.Func = funcCall
+		expr.declaration = string(data)
+		return nil
+	} else if _, ok := errors.Cause(err2).(FunctionUnknownError); ok {
+		return errors.Trace(err2)
+	}
+
+	// Perhaps we have a single item, like true which
+	// occurs occasionally.
+	var v2 BoolExpr
+	err3 := json.Unmarshal(data, &v2)
+	if err3 == nil {
+		expr.Func = nil
+		expr.Literal = []*BoolExpr{&v2}
+		return nil
+	}
+
+	// Return the original error trying to unmarshal the literal expression,
+	// which will be the most expressive.
+	return err
+}
@@ -2,7 +2,11 @@ package parser
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"os"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/juju/errors"
 
@@ -15,17 +19,25 @@ const (
 	FuncNameSelect           = "Select"
 	FuncNameTemplateAttrElem = "TemplateAttrElem"
 	FuncNameTemplateAttr     = "TemplateAttr"
+	FuncNameFromEnv          = "FromEnv"
+	FuncNameFromFile         = "FromFile"
+	FuncNameFindInMap        = "FindInMap"
 )
 
 // Func defines function interface
 type Func interface {
 	isReady(stack utils.StackInterface) (ready bool)
 	getValue(stack utils.StackInterface) (value interface{}, err error)
+	references() (names []string)
 }
 
 // RefFunc defines function that returns returns the value of the specified parameter or resource
 type RefFunc struct {
 	Ref string `json:"Ref"`
+
+	// valueType is type of value expected by the expression, it's empty if value of any
+	// type is accepted
+	valueType string
 }
 
 func (refFunc *RefFunc) isReady(stack utils.StackInterface) (ready bool) {
@@ -40,13 +52,35 @@ func (refFunc *RefFunc) getValue(stack utils.StackInterface) (value interface{},
 	return value, nil
 }
 
+func (refFunc *RefFunc) references() (names []string) {
+	return []string{refFunc.Ref}
+}
+
 func (refFunc *RefFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
 	if err = json.Unmarshal(data, &refFunc.Ref); err != nil {
 		return errors.Trace(err)
 	}
+	refFunc.valueType = valueType
 	return
 }
 
+// acceptAnyType marks value of the expression could be of any type if it's a Ref function,
+// it's used by functions formatting or measuring values referenced by their arguments
+func acceptAnyType(expr ExprType) {
+	var f Func
+	switch e := expr.(type) {
+	case *StringExpr:
+		f = e.Func
+	case *StringListExpr:
+		f = e.Func
+	case *IntegerListExpr:
+		f = e.Func
+	}
+	if refFunc, ok := f.(*RefFunc); ok {
+		refFunc.valueType = ""
+	}
+}
+
 // SelectFunc defines function that returns a single object from a list of objects by index
 type SelectFunc struct {
 	Index    int
@@ -66,6 +100,10 @@ func (selectFunc *SelectFunc) getValue(stack utils.StackInterface) (
 	return
 }
 
+func (selectFunc *SelectFunc) references() (names []string) {
+	return selectFunc.ListExpr.References()
+}
+
 func (selectFunc *SelectFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
 	rawMessages := []json.RawMessage{}
 	if err = json.Unmarshal(data, &rawMessages); err != nil {
@@ -89,6 +127,11 @@ func (selectFunc *SelectFunc) unmarshal(valueType string, data json.RawMessage)
 		if err := json.Unmarshal(rawMessages[1], selectFunc.ListExpr); err != nil {
 			return errors.Trace(err)
 		}
+	case ValueTypeBool:
+		selectFunc.ListExpr = new(BoolListExpr)
+		if err := json.Unmarshal(rawMessages[1], selectFunc.ListExpr); err != nil {
+			return errors.Trace(err)
+		}
 	default:
 		return errors.Errorf("cannot decode function")
 	}
@@ -133,6 +176,10 @@ func (templateAttrElemenFunc *TemplateAttrElemenFunc) getValue(stack utils.Stack
 	return tmplRepr[templateAttrElemenFunc.Index][templateAttrElemenFunc.Attr], nil
 }
 
+func (templateAttrElemenFunc *TemplateAttrElemenFunc) references() (names []string) {
+	return []string{templateAttrElemenFunc.Ref}
+}
+
 func (templateAttrElemenFunc *TemplateAttrElemenFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
 	if err = json.Unmarshal(data, &templateAttrElemenFunc); err != nil {
 		return errors.Trace(err)
@@ -200,6 +247,10 @@ func (templateAttrFunc *TemplateAttrFunc) getValue(stack utils.StackInterface) (
 	return values.Interface(), nil
 }
 
+func (templateAttrFunc *TemplateAttrFunc) references() (names []string) {
+	return []string{templateAttrFunc.Ref}
+}
+
 func (templateAttrFunc *TemplateAttrFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
 	if err = json.Unmarshal(data, &templateAttrFunc); err != nil {
 		return errors.Trace(err)
@@ -208,6 +259,188 @@ func (templateAttrFunc *TemplateAttrFunc) unmarshal(valueType string, data json.
 	return nil
 }
 
+// FromEnvFunc defines function that returns value of an environment variable, the value
+// is treated as secret
+type FromEnvFunc struct {
+	Name string
+}
+
+// isReady returns true since the value doesn't depend on other resources, error of missing
+// environment variable is returned by getValue
+func (fromEnvFunc *FromEnvFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return true
+}
+
+func (fromEnvFunc *FromEnvFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	envValue, ok := os.LookupEnv(fromEnvFunc.Name)
+	if !ok {
+		return nil, errors.NotFoundf("environment variable %s", fromEnvFunc.Name)
+	}
+	utils.AddSecret(envValue)
+	return envValue, nil
+}
+
+func (fromEnvFunc *FromEnvFunc) references() (names []string) {
+	return nil
+}
+
+func (fromEnvFunc *FromEnvFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeString {
+		return errors.Errorf("FromEnv func's value type can't be %s", valueType)
+	}
+	if err = json.Unmarshal(data, &fromEnvFunc.Name); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// FromFileFunc defines function that returns content of a file without trailing newlines,
+// the content is treated as secret
+type FromFileFunc struct {
+	Path string
+}
+
+func (fromFileFunc *FromFileFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return true
+}
+
+func (fromFileFunc *FromFileFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	content, err := ioutil.ReadFile(fromFileFunc.Path)
+	if err != nil {
+		return nil, errors.Annotatef(err, "read file %s", fromFileFunc.Path)
+	}
+	fileValue := strings.TrimRight(string(content), "\r\n")
+	utils.AddSecret(fileValue)
+	return fileValue, nil
+}
+
+func (fromFileFunc *FromFileFunc) references() (names []string) {
+	return nil
+}
+
+func (fromFileFunc *FromFileFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeString {
+		return errors.Errorf("FromFile func's value type can't be %s", valueType)
+	}
+	if err = json.Unmarshal(data, &fromFileFunc.Path); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// FindInMapFunc defines function that returns value in Mappings of template by the map name
+// and keys, e.g. {"FindInMap": ["SiteConfig", {"Ref": "site"}, "cidr"]}
+type FindInMapFunc struct {
+	MapName   *StringExpr
+	TopKey    *StringExpr
+	SecondKey *StringExpr
+	ValueType string
+}
+
+func (findInMapFunc *FindInMapFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return findInMapFunc.MapName.IsReady(stack) && findInMapFunc.TopKey.IsReady(stack) &&
+		findInMapFunc.SecondKey.IsReady(stack)
+}
+
+func (findInMapFunc *FindInMapFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	exprs := []*StringExpr{findInMapFunc.MapName, findInMapFunc.TopKey, findInMapFunc.SecondKey}
+	keys := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		key, err := expr.GetValue(stack)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		keys = append(keys, key)
+	}
+	data, err := stack.FindInMap(keys[0], keys[1], keys[2])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	expr := NewExpr(findInMapFunc.ValueType)
+	if err = json.Unmarshal(data, expr); err != nil {
+		return nil, errors.Annotatef(err, "parse value %s of type %s", data, findInMapFunc.ValueType)
+	}
+	if value, err = GetExprValue(stack, expr); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return value, nil
+}
+
+func (findInMapFunc *FindInMapFunc) references() (names []string) {
+	names = append(findInMapFunc.MapName.References(), findInMapFunc.TopKey.References()...)
+	return append(names, findInMapFunc.SecondKey.References()...)
+}
+
+func (findInMapFunc *FindInMapFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if NewExpr(valueType) == nil {
+		return errors.Errorf("FindInMap func's value type can't be %s", valueType)
+	}
+	findInMapFunc.ValueType = valueType
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	if len(rawMessages) != 3 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	exprs := make([]*StringExpr, 0, len(rawMessages))
+	for _, rawMessage := range rawMessages {
+		expr := new(StringExpr)
+		if err = json.Unmarshal(rawMessage, expr); err != nil {
+			return errors.Trace(err)
+		}
+		exprs = append(exprs, expr)
+	}
+	findInMapFunc.MapName, findInMapFunc.TopKey, findInMapFunc.SecondKey = exprs[0], exprs[1], exprs[2]
+	return nil
+}
+
+// unmarshalableFunc is a function which could be unmarshaled from its arguments
+type unmarshalableFunc interface {
+	Func
+	unmarshal(valueType string, data json.RawMessage) error
+}
+
+// funcs are constructors of functions by names
+var funcs = map[string]func() unmarshalableFunc{
+	FuncNameRef:              func() unmarshalableFunc { return new(RefFunc) },
+	FuncNameSelect:           func() unmarshalableFunc { return new(SelectFunc) },
+	FuncNameTemplateAttrElem: func() unmarshalableFunc { return new(TemplateAttrElemenFunc) },
+	FuncNameTemplateAttr:     func() unmarshalableFunc { return new(TemplateAttrFunc) },
+	FuncNameFromEnv:          func() unmarshalableFunc { return new(FromEnvFunc) },
+	FuncNameFromFile:         func() unmarshalableFunc { return new(FromFileFunc) },
+	FuncNameFindInMap:        func() unmarshalableFunc { return new(FindInMapFunc) },
+	FuncNameSub:              func() unmarshalableFunc { return new(SubFunc) },
+	FuncNameJoin:             func() unmarshalableFunc { return new(JoinFunc) },
+	FuncNameSplit:            func() unmarshalableFunc { return new(SplitFunc) },
+	FuncNameConcat:           func() unmarshalableFunc { return new(ConcatFunc) },
+	FuncNameEquals:           func() unmarshalableFunc { return new(EqualsFunc) },
+	FuncNameAnd:              func() unmarshalableFunc { return new(AndFunc) },
+	FuncNameOr:               func() unmarshalableFunc { return new(OrFunc) },
+	FuncNameNot:              func() unmarshalableFunc { return new(NotFunc) },
+	FuncNameCondition:        func() unmarshalableFunc { return new(ConditionFunc) },
+	FuncNameIf:               func() unmarshalableFunc { return new(IfFunc) },
+	FuncNameAdd:              func() unmarshalableFunc { return new(AddFunc) },
+	FuncNameSum:              func() unmarshalableFunc { return new(AddFunc) },
+	FuncNameMultiply:         func() unmarshalableFunc { return new(MultiplyFunc) },
+	FuncNameDivide:           func() unmarshalableFunc { return new(DivideFunc) },
+	FuncNameLength:           func() unmarshalableFunc { return new(LengthFunc) },
+	FuncNameRange:            func() unmarshalableFunc { return new(RangeFunc) },
+	FuncNameSlice:            func() unmarshalableFunc { return new(SliceFunc) },
+	FuncNameUnique:           func() unmarshalableFunc { return new(UniqueFunc) },
+	FuncNameFlatten:          func() unmarshalableFunc { return new(FlattenFunc) },
+}
+
+// FuncNames returns names of all functions in order
+func FuncNames() []string {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func unmarshalFunc(valueType string, data []byte) (Func, error) {
 	rawDecode := map[string]json.RawMessage{}
 	err := json.Unmarshal(data, &rawDecode)
@@ -215,33 +448,14 @@ func unmarshalFunc(valueType string, data []byte) (Func, error) {
 		return nil, err
 	}
 	for funcName, funcData := range rawDecode {
-		switch funcName {
-		case FuncNameRef:
-			f := new(RefFunc)
-			if err = f.unmarshal(valueType, funcData); err == nil {
-				return f, nil
-			}
-		case FuncNameSelect:
-			f := new(SelectFunc)
-			if err = f.unmarshal(valueType, funcData); err == nil {
-				return f, nil
-			}
-		case FuncNameTemplateAttrElem:
-			f := new(TemplateAttrElemenFunc)
-			if err = f.unmarshal(valueType, funcData); err == nil {
-				return f, nil
-			}
-		case FuncNameTemplateAttr:
-			f := new(TemplateAttrFunc)
-			if valueType != ValueTypeStringList && valueType != ValueTypeIntegerList {
-				errors.Errorf("TemplateAttr func's value type can't be %s", valueType)
-			}
-			if err = f.unmarshal(valueType, funcData); err == nil {
-				return f, nil
-			}
-		default:
+		newFunc, ok := funcs[funcName]
+		if !ok {
 			return nil, FunctionUnknownError{FunctionName: funcName}
 		}
+		f := newFunc()
+		if err = f.unmarshal(valueType, funcData); err == nil {
+			return f, nil
+		}
 	}
 	return nil, errors.Errorf("cannot decode function")
 }
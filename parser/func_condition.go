@@ -0,0 +1,319 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
+
+// consts for names of condition functions
+const (
+	FuncNameEquals    = "Equals"
+	FuncNameAnd       = "And"
+	FuncNameOr        = "Or"
+	FuncNameNot       = "Not"
+	FuncNameCondition = "Condition"
+	FuncNameIf        = "If"
+)
+
+// unmarshalBoolExprs unmarshals a list of bool expressions, a single expression is also accepted
+func unmarshalBoolExprs(data json.RawMessage) ([]*BoolExpr, error) {
+	rawMessages := []json.RawMessage{}
+	if err := json.Unmarshal(data, &rawMessages); err != nil {
+		rawMessages = []json.RawMessage{data}
+	}
+	exprs := make([]*BoolExpr, 0, len(rawMessages))
+	for _, rawMessage := range rawMessages {
+		expr := new(BoolExpr)
+		if err := json.Unmarshal(rawMessage, expr); err != nil {
+			return nil, errors.Trace(err)
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+// EqualsFunc defines function that returns whether two values are equal, values are compared
+// as strings, e.g. {"Equals": [{"Ref": "disk_type"}, "SSD"]}
+type EqualsFunc struct {
+	Exprs []*StringExpr
+}
+
+func (equalsFunc *EqualsFunc) isReady(stack utils.StackInterface) (ready bool) {
+	for _, expr := range equalsFunc.Exprs {
+		if !expr.IsReady(stack) {
+			return false
+		}
+	}
+	return true
+}
+
+func (equalsFunc *EqualsFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	values := make([]string, 0, len(equalsFunc.Exprs))
+	for _, expr := range equalsFunc.Exprs {
+		str, err := getStringValue(stack, expr)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		values = append(values, str)
+	}
+	return values[0] == values[1], nil
+}
+
+func (equalsFunc *EqualsFunc) references() (names []string) {
+	for _, expr := range equalsFunc.Exprs {
+		names = append(names, expr.References()...)
+	}
+	return names
+}
+
+func (equalsFunc *EqualsFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeBool {
+		return errors.Errorf("Equals func's value type can't be %s", valueType)
+	}
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	if len(rawMessages) != 2 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	for _, rawMessage := range rawMessages {
+		expr := new(StringExpr)
+		// numbers and bools are compared as strings
+		if literal := bytes.TrimSpace(rawMessage); len(literal) != 0 &&
+			literal[0] != '"' && literal[0] != '{' && literal[0] != '[' {
+
+			expr.Literal = string(literal)
+		} else if err = json.Unmarshal(rawMessage, expr); err != nil {
+			return errors.Trace(err)
+		}
+		acceptAnyType(expr)
+		equalsFunc.Exprs = append(equalsFunc.Exprs, expr)
+	}
+	return nil
+}
+
+// AndFunc defines function that returns true if all conditions are true
+type AndFunc struct {
+	Exprs []*BoolExpr
+}
+
+func (andFunc *AndFunc) isReady(stack utils.StackInterface) (ready bool) {
+	for _, expr := range andFunc.Exprs {
+		if !expr.IsReady(stack) {
+			return false
+		}
+	}
+	return true
+}
+
+func (andFunc *AndFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	for _, expr := range andFunc.Exprs {
+		result, err := expr.GetValue(stack)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !result {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (andFunc *AndFunc) references() (names []string) {
+	for _, expr := range andFunc.Exprs {
+		names = append(names, expr.References()...)
+	}
+	return names
+}
+
+func (andFunc *AndFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeBool {
+		return errors.Errorf("And func's value type can't be %s", valueType)
+	}
+	if andFunc.Exprs, err = unmarshalBoolExprs(data); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// OrFunc defines function that returns true if any condition is true
+type OrFunc struct {
+	Exprs []*BoolExpr
+}
+
+func (orFunc *OrFunc) isReady(stack utils.StackInterface) (ready bool) {
+	for _, expr := range orFunc.Exprs {
+		if !expr.IsReady(stack) {
+			return false
+		}
+	}
+	return true
+}
+
+func (orFunc *OrFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	for _, expr := range orFunc.Exprs {
+		result, err := expr.GetValue(stack)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if result {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (orFunc *OrFunc) references() (names []string) {
+	for _, expr := range orFunc.Exprs {
+		names = append(names, expr.References()...)
+	}
+	return names
+}
+
+func (orFunc *OrFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeBool {
+		return errors.Errorf("Or func's value type can't be %s", valueType)
+	}
+	if orFunc.Exprs, err = unmarshalBoolExprs(data); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// NotFunc defines function that returns the inverse of a condition
+type NotFunc struct {
+	Expr *BoolExpr
+}
+
+func (notFunc *NotFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return notFunc.Expr.IsReady(stack)
+}
+
+func (notFunc *NotFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	result, err := notFunc.Expr.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return !result, nil
+}
+
+func (notFunc *NotFunc) references() (names []string) {
+	return notFunc.Expr.References()
+}
+
+func (notFunc *NotFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeBool {
+		return errors.Errorf("Not func's value type can't be %s", valueType)
+	}
+	exprs, err := unmarshalBoolExprs(data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(exprs) != 1 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	notFunc.Expr = exprs[0]
+	return nil
+}
+
+// ConditionFunc defines function that returns value of a condition defined in Conditions
+type ConditionFunc struct {
+	Condition string
+}
+
+func (conditionFunc *ConditionFunc) isReady(stack utils.StackInterface) (ready bool) {
+	_, err := stack.GetCondition(conditionFunc.Condition)
+	return err == nil
+}
+
+func (conditionFunc *ConditionFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	if value, err = stack.GetCondition(conditionFunc.Condition); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return value, nil
+}
+
+func (conditionFunc *ConditionFunc) references() (names []string) {
+	return nil
+}
+
+func (conditionFunc *ConditionFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeBool {
+		return errors.Errorf("Condition func's value type can't be %s", valueType)
+	}
+	if err = json.Unmarshal(data, &conditionFunc.Condition); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// IfFunc defines function that returns the first value if the condition is true, otherwise
+// the second value, e.g. {"If": ["WithSSD", "SSD", "HDD"]}
+type IfFunc struct {
+	Condition string
+	TrueExpr  ExprType
+	FalseExpr ExprType
+}
+
+func (ifFunc *IfFunc) getExpr(stack utils.StackInterface) (ExprType, error) {
+	condition, err := stack.GetCondition(ifFunc.Condition)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if condition {
+		return ifFunc.TrueExpr, nil
+	}
+	return ifFunc.FalseExpr, nil
+}
+
+func (ifFunc *IfFunc) isReady(stack utils.StackInterface) (ready bool) {
+	expr, err := ifFunc.getExpr(stack)
+	if err != nil {
+		return false
+	}
+	return expr.IsReady(stack)
+}
+
+func (ifFunc *IfFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	expr, err := ifFunc.getExpr(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if value, err = GetExprValue(stack, expr); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return value, nil
+}
+
+func (ifFunc *IfFunc) references() (names []string) {
+	return append(ifFunc.TrueExpr.References(), ifFunc.FalseExpr.References()...)
+}
+
+func (ifFunc *IfFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	if len(rawMessages) != 3 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	if err = json.Unmarshal(rawMessages[0], &ifFunc.Condition); err != nil {
+		return errors.Trace(err)
+	}
+	ifFunc.TrueExpr, ifFunc.FalseExpr = NewExpr(valueType), NewExpr(valueType)
+	if ifFunc.TrueExpr == nil {
+		return errors.Errorf("If func's value type can't be %s", valueType)
+	}
+	if err = json.Unmarshal(rawMessages[1], ifFunc.TrueExpr); err != nil {
+		return errors.Trace(err)
+	}
+	if err = json.Unmarshal(rawMessages[2], ifFunc.FalseExpr); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
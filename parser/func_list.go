@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
+
+// consts for names of list functions
+const (
+	FuncNameRange   = "Range"
+	FuncNameSlice   = "Slice"
+	FuncNameUnique  = "Unique"
+	FuncNameFlatten = "Flatten"
+)
+
+// newListExpr returns an empty list expression of the value type, nil is returned if the
+// value type is not a list type
+func newListExpr(valueType string) ListExprType {
+	switch valueType {
+	case ValueTypeBoolList:
+		return new(BoolListExpr)
+	case ValueTypeStringList:
+		return new(StringListExpr)
+	case ValueTypeIntegerList:
+		return new(IntegerListExpr)
+	}
+	return nil
+}
+
+// RangeFunc defines function that returns a list of integers from start to stop (exclusive)
+// by step, e.g. {"Range": 3} returns [0, 1, 2], {"Range": [1, 7, 2]} returns [1, 3, 5]
+type RangeFunc struct {
+	ArgsExpr *IntegerListExpr
+}
+
+func (rangeFunc *RangeFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return rangeFunc.ArgsExpr.IsReady(stack)
+}
+
+func (rangeFunc *RangeFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	args, err := rangeFunc.ArgsExpr.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var start, stop, step int64 = 0, 0, 1
+	switch len(args) {
+	case 1:
+		stop = args[0]
+	case 2:
+		start, stop = args[0], args[1]
+	case 3:
+		start, stop, step = args[0], args[1], args[2]
+	default:
+		return nil, errors.Errorf("Range func requires 1 to 3 arguments, got %d", len(args))
+	}
+	if step == 0 {
+		return nil, errors.Errorf("step of Range func can't be zero")
+	}
+	integers := []int64{}
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		integers = append(integers, i)
+	}
+	return integers, nil
+}
+
+func (rangeFunc *RangeFunc) references() (names []string) {
+	return rangeFunc.ArgsExpr.References()
+}
+
+func (rangeFunc *RangeFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeIntegerList {
+		return errors.Errorf("Range func's value type can't be %s", valueType)
+	}
+	rangeFunc.ArgsExpr = new(IntegerListExpr)
+	if err = json.Unmarshal(data, rangeFunc.ArgsExpr); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// SliceFunc defines function that returns items of a list from start to end (exclusive),
+// e.g. {"Slice": [0, 3, {"Ref": "host_ids"}]}
+type SliceFunc struct {
+	Start    *IntegerExpr
+	End      *IntegerExpr
+	ListExpr ListExprType
+}
+
+func (sliceFunc *SliceFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return sliceFunc.Start.IsReady(stack) && sliceFunc.End.IsReady(stack) &&
+		sliceFunc.ListExpr.IsReady(stack)
+}
+
+func (sliceFunc *SliceFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	start, err := sliceFunc.Start.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	end, err := sliceFunc.End.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	listValue, err := GetExprValue(stack, sliceFunc.ListExpr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	listVal := reflect.ValueOf(listValue)
+	if start < 0 || start > end || end > int64(listVal.Len()) {
+		return nil, errors.Errorf("invalid range [%d:%d] for value of %s",
+			start, end, sliceFunc.ListExpr.GetDeclaration())
+	}
+	return listVal.Slice(int(start), int(end)).Interface(), nil
+}
+
+func (sliceFunc *SliceFunc) references() (names []string) {
+	names = append(sliceFunc.Start.References(), sliceFunc.End.References()...)
+	return append(names, sliceFunc.ListExpr.References()...)
+}
+
+func (sliceFunc *SliceFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if sliceFunc.ListExpr = newListExpr(valueType); sliceFunc.ListExpr == nil {
+		return errors.Errorf("Slice func's value type can't be %s", valueType)
+	}
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	if len(rawMessages) != 3 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	sliceFunc.Start, sliceFunc.End = new(IntegerExpr), new(IntegerExpr)
+	if err = json.Unmarshal(rawMessages[0], sliceFunc.Start); err != nil {
+		return errors.Trace(err)
+	}
+	if err = json.Unmarshal(rawMessages[1], sliceFunc.End); err != nil {
+		return errors.Trace(err)
+	}
+	if err = json.Unmarshal(rawMessages[2], sliceFunc.ListExpr); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// UniqueFunc defines function that removes duplicate items of a list and keeps the order,
+// e.g. {"Unique": {"Ref": "pool_ids"}}
+type UniqueFunc struct {
+	ListExpr ListExprType
+}
+
+func (uniqueFunc *UniqueFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return uniqueFunc.ListExpr.IsReady(stack)
+}
+
+func (uniqueFunc *UniqueFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	listValue, err := GetExprValue(stack, uniqueFunc.ListExpr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	listVal := reflect.ValueOf(listValue)
+	values := reflect.MakeSlice(listVal.Type(), 0, listVal.Len())
+	existed := map[interface{}]bool{}
+	for i := 0; i < listVal.Len(); i++ {
+		item := listVal.Index(i)
+		if !existed[item.Interface()] {
+			existed[item.Interface()] = true
+			values = reflect.Append(values, item)
+		}
+	}
+	return values.Interface(), nil
+}
+
+func (uniqueFunc *UniqueFunc) references() (names []string) {
+	return uniqueFunc.ListExpr.References()
+}
+
+func (uniqueFunc *UniqueFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if uniqueFunc.ListExpr = newListExpr(valueType); uniqueFunc.ListExpr == nil {
+		return errors.Errorf("Unique func's value type can't be %s", valueType)
+	}
+	if err = json.Unmarshal(data, uniqueFunc.ListExpr); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// FlattenFunc defines function that flattens nested lists into a single list,
+// e.g. {"Flatten": [[1, 2], {"Ref": "host_ids"}, [3, [4, 5]]]}
+type FlattenFunc struct {
+	Exprs []ListExprType
+}
+
+func (flattenFunc *FlattenFunc) isReady(stack utils.StackInterface) (ready bool) {
+	for _, expr := range flattenFunc.Exprs {
+		if !expr.IsReady(stack) {
+			return false
+		}
+	}
+	return true
+}
+
+func (flattenFunc *FlattenFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	var values reflect.Value
+	for _, expr := range flattenFunc.Exprs {
+		listValue, err := GetExprValue(stack, expr)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !values.IsValid() {
+			values = reflect.MakeSlice(reflect.TypeOf(listValue), 0, 0)
+		}
+		values = reflect.AppendSlice(values, reflect.ValueOf(listValue))
+	}
+	return values.Interface(), nil
+}
+
+func (flattenFunc *FlattenFunc) references() (names []string) {
+	for _, expr := range flattenFunc.Exprs {
+		names = append(names, expr.References()...)
+	}
+	return names
+}
+
+// unmarshalItems unmarshals items of nested lists into list expressions
+func (flattenFunc *FlattenFunc) unmarshalItems(valueType string, data json.RawMessage) (err error) {
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		// single item or function that returns a list
+		expr := newListExpr(valueType)
+		if err = json.Unmarshal(data, expr); err != nil {
+			return errors.Trace(err)
+		}
+		flattenFunc.Exprs = append(flattenFunc.Exprs, expr)
+		return nil
+	}
+	for _, rawMessage := range rawMessages {
+		if err = flattenFunc.unmarshalItems(valueType, rawMessage); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (flattenFunc *FlattenFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if newListExpr(valueType) == nil {
+		return errors.Errorf("Flatten func's value type can't be %s", valueType)
+	}
+	if err = flattenFunc.unmarshalItems(valueType, data); err != nil {
+		return errors.Trace(err)
+	}
+	if len(flattenFunc.Exprs) == 0 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
+
+// consts for names of integer functions
+const (
+	FuncNameAdd      = "Add"
+	FuncNameSum      = "Sum"
+	FuncNameMultiply = "Multiply"
+	FuncNameDivide   = "Divide"
+	FuncNameLength   = "Length"
+)
+
+// AddFunc defines function that returns sum of integers, e.g. {"Add": [{"Ref": "replica"}, 1]},
+// items of integer lists are added too, e.g. {"Sum": {"Ref": "disk_sizes"}}
+type AddFunc struct {
+	ListExpr *IntegerListExpr
+}
+
+func (addFunc *AddFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return addFunc.ListExpr.IsReady(stack)
+}
+
+func (addFunc *AddFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	items, err := addFunc.ListExpr.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var sum int64
+	for _, item := range items {
+		sum += item
+	}
+	return sum, nil
+}
+
+func (addFunc *AddFunc) references() (names []string) {
+	return addFunc.ListExpr.References()
+}
+
+func (addFunc *AddFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeInteger {
+		return errors.Errorf("Add func's value type can't be %s", valueType)
+	}
+	addFunc.ListExpr = new(IntegerListExpr)
+	if err = json.Unmarshal(data, addFunc.ListExpr); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// MultiplyFunc defines function that returns product of integers,
+// e.g. {"Multiply": [{"Ref": "osd_per_ssd"}, 2]}
+type MultiplyFunc struct {
+	ListExpr *IntegerListExpr
+}
+
+func (multiplyFunc *MultiplyFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return multiplyFunc.ListExpr.IsReady(stack)
+}
+
+func (multiplyFunc *MultiplyFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	items, err := multiplyFunc.ListExpr.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	product := int64(1)
+	for _, item := range items {
+		product *= item
+	}
+	return product, nil
+}
+
+func (multiplyFunc *MultiplyFunc) references() (names []string) {
+	return multiplyFunc.ListExpr.References()
+}
+
+func (multiplyFunc *MultiplyFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeInteger {
+		return errors.Errorf("Multiply func's value type can't be %s", valueType)
+	}
+	multiplyFunc.ListExpr = new(IntegerListExpr)
+	if err = json.Unmarshal(data, multiplyFunc.ListExpr); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// DivideFunc defines function that returns quotient of two integers, the result is truncated,
+// e.g. {"Divide": [{"Ref": "disk_num"}, 2]}
+type DivideFunc struct {
+	Dividend *IntegerExpr
+	Divisor  *IntegerExpr
+}
+
+func (divideFunc *DivideFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return divideFunc.Dividend.IsReady(stack) && divideFunc.Divisor.IsReady(stack)
+}
+
+func (divideFunc *DivideFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	dividend, err := divideFunc.Dividend.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	divisor, err := divideFunc.Divisor.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if divisor == 0 {
+		return nil, errors.Errorf("divide %d by zero", dividend)
+	}
+	return dividend / divisor, nil
+}
+
+func (divideFunc *DivideFunc) references() (names []string) {
+	return append(divideFunc.Dividend.References(), divideFunc.Divisor.References()...)
+}
+
+func (divideFunc *DivideFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeInteger {
+		return errors.Errorf("Divide func's value type can't be %s", valueType)
+	}
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	if len(rawMessages) != 2 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	divideFunc.Dividend, divideFunc.Divisor = new(IntegerExpr), new(IntegerExpr)
+	if err = json.Unmarshal(rawMessages[0], divideFunc.Dividend); err != nil {
+		return errors.Trace(err)
+	}
+	if err = json.Unmarshal(rawMessages[1], divideFunc.Divisor); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// LengthFunc defines function that returns number of items in a list,
+// e.g. {"Length": {"Ref": "host_ids"}}
+type LengthFunc struct {
+	ListExpr ExprType
+}
+
+func (lengthFunc *LengthFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return lengthFunc.ListExpr.IsReady(stack)
+}
+
+func (lengthFunc *LengthFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	var listFunc Func
+	switch e := lengthFunc.ListExpr.(type) {
+	case *StringListExpr:
+		if e.Func == nil {
+			return int64(len(e.Literal)), nil
+		}
+		listFunc = e.Func
+	case *IntegerListExpr:
+		if e.Func == nil {
+			return int64(len(e.Literal)), nil
+		}
+		listFunc = e.Func
+	}
+	// referenced list could be list of any type
+	listValue, err := listFunc.getValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if listVal := reflect.ValueOf(listValue); listVal.Kind() == reflect.Slice {
+		return int64(listVal.Len()), nil
+	}
+	return int64(1), nil
+}
+
+func (lengthFunc *LengthFunc) references() (names []string) {
+	return lengthFunc.ListExpr.References()
+}
+
+func (lengthFunc *LengthFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeInteger {
+		return errors.Errorf("Length func's value type can't be %s", valueType)
+	}
+	stringListExpr := new(StringListExpr)
+	if err = json.Unmarshal(data, stringListExpr); err == nil {
+		lengthFunc.ListExpr = stringListExpr
+		acceptAnyType(stringListExpr)
+		return nil
+	}
+	integerListExpr := new(IntegerListExpr)
+	if err = json.Unmarshal(data, integerListExpr); err != nil {
+		return errors.Trace(err)
+	}
+	lengthFunc.ListExpr = integerListExpr
+	acceptAnyType(integerListExpr)
+	return nil
+}
@@ -0,0 +1,371 @@
+package parser
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
+
+// consts for names of string functions
+const (
+	FuncNameSub    = "Sub"
+	FuncNameJoin   = "Join"
+	FuncNameSplit  = "Split"
+	FuncNameConcat = "Concat"
+)
+
+// subVariablePattern matches ${name} in string of Sub function, ${!name} is written as ${name}
+var subVariablePattern = regexp.MustCompile(`\$\{(!?)([^}]+)\}`)
+
+// formatValue returns string of a value, items of list are separated by comma
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	val := reflect.ValueOf(value)
+	if val.Kind() != reflect.Slice {
+		return "", errors.Errorf("unsupported value %v of type %T", value, value)
+	}
+	items := make([]string, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		item, err := formatValue(val.Index(i).Interface())
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ","), nil
+}
+
+// getStringValue returns value of string expression, value of function is formatted so that
+// integers and lists could be used as strings
+func getStringValue(stack utils.StackInterface, expr *StringExpr) (string, error) {
+	if expr.Func == nil {
+		return expr.Literal, nil
+	}
+	value, err := expr.Func.getValue(stack)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	str, err := formatValue(value)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return str, nil
+}
+
+// SubFunc defines function that substitutes variables in a string with their values,
+// e.g. {"Sub": "vol-${index}"} or {"Sub": ["vol-${name}", {"name": {"Ref": "prefix"}}]},
+// variables not set in the map are parameters, resources or template contexts
+type SubFunc struct {
+	String    string
+	Variables map[string]*StringExpr
+}
+
+func (subFunc *SubFunc) getVariableNames() (names []string) {
+	for _, match := range subVariablePattern.FindAllStringSubmatch(subFunc.String, -1) {
+		if match[1] == "" {
+			names = append(names, match[2])
+		}
+	}
+	return names
+}
+
+func (subFunc *SubFunc) isReady(stack utils.StackInterface) (ready bool) {
+	for _, name := range subFunc.getVariableNames() {
+		if expr, ok := subFunc.Variables[name]; ok {
+			if !expr.IsReady(stack) {
+				return false
+			}
+		} else if stack.GetResourceValue(name) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (subFunc *SubFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	value = subVariablePattern.ReplaceAllStringFunc(subFunc.String, func(variable string) string {
+		if err != nil {
+			return variable
+		}
+		match := subVariablePattern.FindStringSubmatch(variable)
+		if match[1] != "" {
+			return "${" + match[2] + "}"
+		}
+		var result string
+		if expr, ok := subFunc.Variables[match[2]]; ok {
+			result, err = getStringValue(stack, expr)
+			return result
+		}
+		variableValue := stack.GetResourceValue(match[2])
+		if variableValue == nil {
+			err = ExpressionParamInsufficientError{ParamName: match[2]}
+			return variable
+		}
+		result, err = formatValue(variableValue)
+		return result
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "substitute %s", subFunc.String)
+	}
+	return value, nil
+}
+
+func (subFunc *SubFunc) references() (names []string) {
+	for _, name := range subFunc.getVariableNames() {
+		if expr, ok := subFunc.Variables[name]; ok {
+			names = append(names, expr.References()...)
+		} else {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (subFunc *SubFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeString {
+		return errors.Errorf("Sub func's value type can't be %s", valueType)
+	}
+	if err = json.Unmarshal(data, &subFunc.String); err == nil {
+		return nil
+	}
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	if len(rawMessages) != 2 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	if err = json.Unmarshal(rawMessages[0], &subFunc.String); err != nil {
+		return errors.Trace(err)
+	}
+	if err = json.Unmarshal(rawMessages[1], &subFunc.Variables); err != nil {
+		return errors.Trace(err)
+	}
+	for _, expr := range subFunc.Variables {
+		acceptAnyType(expr)
+	}
+	return nil
+}
+
+// JoinFunc defines function that joins items of a list with the delimiter,
+// e.g. {"Join": [",", {"Ref": "admin_ips"}]}
+type JoinFunc struct {
+	Delimiter string
+	ListExpr  *StringListExpr
+}
+
+func (joinFunc *JoinFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return joinFunc.ListExpr.IsReady(stack)
+}
+
+func (joinFunc *JoinFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	var items []string
+	if joinFunc.ListExpr.Func != nil {
+		// referenced list could be list of any type
+		listValue, err := joinFunc.ListExpr.Func.getValue(stack)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		listVal := reflect.ValueOf(listValue)
+		if listVal.Kind() != reflect.Slice {
+			listValue = []interface{}{listValue}
+			listVal = reflect.ValueOf(listValue)
+		}
+		for i := 0; i < listVal.Len(); i++ {
+			item, err := formatValue(listVal.Index(i).Interface())
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			items = append(items, item)
+		}
+	} else if items, err = joinFunc.ListExpr.GetValue(stack); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return strings.Join(items, joinFunc.Delimiter), nil
+}
+
+func (joinFunc *JoinFunc) references() (names []string) {
+	return joinFunc.ListExpr.References()
+}
+
+func (joinFunc *JoinFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeString {
+		return errors.Errorf("Join func's value type can't be %s", valueType)
+	}
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	if len(rawMessages) != 2 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	if err = json.Unmarshal(rawMessages[0], &joinFunc.Delimiter); err != nil {
+		return errors.Trace(err)
+	}
+	joinFunc.ListExpr = new(StringListExpr)
+	if err = json.Unmarshal(rawMessages[1], joinFunc.ListExpr); err != nil {
+		return errors.Trace(err)
+	}
+	acceptAnyType(joinFunc.ListExpr)
+	return nil
+}
+
+// SplitFunc defines function that splits a string into a list by the delimiter,
+// e.g. {"Split": [",", "10.0.0.1,10.0.0.2"]}
+type SplitFunc struct {
+	Delimiter string
+	Expr      *StringExpr
+	ValueType string
+}
+
+func (splitFunc *SplitFunc) isReady(stack utils.StackInterface) (ready bool) {
+	return splitFunc.Expr.IsReady(stack)
+}
+
+func (splitFunc *SplitFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	str, err := splitFunc.Expr.GetValue(stack)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	items := []string{}
+	if str != "" {
+		items = strings.Split(str, splitFunc.Delimiter)
+	}
+	if splitFunc.ValueType == ValueTypeStringList {
+		return items, nil
+	}
+	integers := make([]int64, 0, len(items))
+	for _, item := range items {
+		integer, err := strconv.ParseInt(strings.TrimSpace(item), 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("item %q of %q is not integer", item, str)
+		}
+		integers = append(integers, integer)
+	}
+	return integers, nil
+}
+
+func (splitFunc *SplitFunc) references() (names []string) {
+	return splitFunc.Expr.References()
+}
+
+func (splitFunc *SplitFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	if valueType != ValueTypeStringList && valueType != ValueTypeIntegerList {
+		return errors.Errorf("Split func's value type can't be %s", valueType)
+	}
+	splitFunc.ValueType = valueType
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	if len(rawMessages) != 2 {
+		return errors.Errorf("cannot decode fuction")
+	}
+	if err = json.Unmarshal(rawMessages[0], &splitFunc.Delimiter); err != nil {
+		return errors.Trace(err)
+	}
+	splitFunc.Expr = new(StringExpr)
+	if err = json.Unmarshal(rawMessages[1], splitFunc.Expr); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// ConcatFunc defines function that concatenates strings or lists,
+// e.g. {"Concat": ["pool-", {"Ref": "name"}]} or {"Concat": [{"Ref": "ids1"}, [1, 2]]}
+type ConcatFunc struct {
+	Exprs     []ExprType
+	ValueType string
+}
+
+func (concatFunc *ConcatFunc) isReady(stack utils.StackInterface) (ready bool) {
+	for _, expr := range concatFunc.Exprs {
+		if !expr.IsReady(stack) {
+			return false
+		}
+	}
+	return true
+}
+
+func (concatFunc *ConcatFunc) getValue(stack utils.StackInterface) (value interface{}, err error) {
+	var str string
+	strList, integerList := []string{}, []int64{}
+	for _, expr := range concatFunc.Exprs {
+		switch e := expr.(type) {
+		case *StringExpr:
+			item, err := getStringValue(stack, e)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			str += item
+		case *StringListExpr:
+			items, err := e.GetValue(stack)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			strList = append(strList, items...)
+		case *IntegerListExpr:
+			items, err := e.GetValue(stack)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			integerList = append(integerList, items...)
+		}
+	}
+	switch concatFunc.ValueType {
+	case ValueTypeString:
+		return str, nil
+	case ValueTypeStringList:
+		return strList, nil
+	}
+	return integerList, nil
+}
+
+func (concatFunc *ConcatFunc) references() (names []string) {
+	for _, expr := range concatFunc.Exprs {
+		names = append(names, expr.References()...)
+	}
+	return names
+}
+
+func (concatFunc *ConcatFunc) unmarshal(valueType string, data json.RawMessage) (err error) {
+	concatFunc.ValueType = valueType
+	rawMessages := []json.RawMessage{}
+	if err = json.Unmarshal(data, &rawMessages); err != nil {
+		return errors.Trace(err)
+	}
+	for _, rawMessage := range rawMessages {
+		var expr ExprType
+		switch valueType {
+		case ValueTypeString:
+			expr = new(StringExpr)
+		case ValueTypeStringList:
+			expr = new(StringListExpr)
+		case ValueTypeIntegerList:
+			expr = new(IntegerListExpr)
+		default:
+			return errors.Errorf("Concat func's value type can't be %s", valueType)
+		}
+		if err = json.Unmarshal(rawMessage, expr); err != nil {
+			return errors.Trace(err)
+		}
+		if valueType == ValueTypeString {
+			acceptAnyType(expr)
+		}
+		concatFunc.Exprs = append(concatFunc.Exprs, expr)
+	}
+	return nil
+}
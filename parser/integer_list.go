@@ -63,6 +63,17 @@ func (expr *IntegerListExpr) GetValue(stack utils.StackInterface) (value []int64
 	return value, nil
 }
 
+// References returns names of parameters and resources referenced by integer list expression
+func (expr *IntegerListExpr) References() (names []string) {
+	if expr.Func != nil {
+		return expr.Func.references()
+	}
+	for _, subExpr := range expr.Literal {
+		names = append(names, subExpr.References()...)
+	}
+	return names
+}
+
 // Select returns value of list expression by index
 func (expr *IntegerListExpr) Select(stack utils.StackInterface, index int) (interface{}, error) {
 	value, err := expr.GetValue(stack)
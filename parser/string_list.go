@@ -63,6 +63,17 @@ func (expr *StringListExpr) GetValue(stack utils.StackInterface) (value []string
 	return value, nil
 }
 
+// References returns names of parameters and resources referenced by string list expression
+func (expr *StringListExpr) References() (names []string) {
+	if expr.Func != nil {
+		return expr.Func.references()
+	}
+	for _, subExpr := range expr.Literal {
+		names = append(names, subExpr.References()...)
+	}
+	return names
+}
+
 // Select returns value of list expression by index
 func (expr *StringListExpr) Select(stack utils.StackInterface, index int) (interface{}, error) {
 	value, err := expr.GetValue(stack)
@@ -0,0 +1,317 @@
+package formation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/stretchr/testify/suite"
+
+	"xsky.com/sds-formation/parser"
+	"xsky.com/sds-formation/utils"
+)
+
+type parserFuncSuite struct {
+	suite.Suite
+
+	stack *Stack
+}
+
+func (s *parserFuncSuite) SetupTest() {
+	s.stack = new(Stack)
+	s.stack.resourceValueMap = map[string]interface{}{}
+}
+
+func (s *parserFuncSuite) getValue(exprType, data string) (interface{}, error) {
+	var expr parser.ExprType
+	switch exprType {
+	case parser.ValueTypeInteger:
+		expr = new(parser.IntegerExpr)
+	case parser.ValueTypeIntegerList:
+		expr = new(parser.IntegerListExpr)
+	case parser.ValueTypeStringList:
+		expr = new(parser.StringListExpr)
+	case parser.ValueTypeBool:
+		expr = new(parser.BoolExpr)
+	case parser.ValueTypeBoolList:
+		expr = new(parser.BoolListExpr)
+	default:
+		expr = new(parser.StringExpr)
+	}
+	if err := json.Unmarshal([]byte(data), expr); err != nil {
+		return nil, err
+	}
+	return parser.GetExprValue(s.stack, expr)
+}
+
+func (s *parserFuncSuite) TestFromEnv() {
+	os.Setenv("FORMATION_TEST_PASSWORD", "env-password")
+	defer os.Unsetenv("FORMATION_TEST_PASSWORD")
+
+	value, err := s.getValue(parser.ValueTypeString, `{"FromEnv": "FORMATION_TEST_PASSWORD"}`)
+	s.NoError(err)
+	s.Equal("env-password", value)
+	s.Equal("password ******", utils.Redact("password env-password"))
+
+	_, err = s.getValue(parser.ValueTypeString, `{"FromEnv": "FORMATION_TEST_NOT_EXIST"}`)
+	s.Error(err)
+	_, err = s.getValue(parser.ValueTypeInteger, `{"FromEnv": "FORMATION_TEST_PASSWORD"}`)
+	s.Error(err)
+}
+
+func (s *parserFuncSuite) TestFromFile() {
+	dir, err := ioutil.TempDir("", "formation-secret")
+	s.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ldap_pw")
+	s.NoError(ioutil.WriteFile(path, []byte("file-password\n"), 0600))
+
+	value, err := s.getValue(parser.ValueTypeString, `{"FromFile": "`+path+`"}`)
+	s.NoError(err)
+	s.Equal("file-password", value)
+	s.Equal("password ******", utils.Redact("password file-password"))
+
+	_, err = s.getValue(parser.ValueTypeString, `{"FromFile": "`+filepath.Join(dir, "none")+`"}`)
+	s.Error(err)
+}
+
+func (s *parserFuncSuite) TestSub() {
+	s.stack.resourceValueMap = map[string]interface{}{"Prefix": "pool", "host_ids": []int64{1, 2}}
+	s.stack.templateContext = map[string]interface{}{"index": int64(3)}
+
+	value, err := s.getValue(parser.ValueTypeString, `{"Sub": "vol-${index}-${Prefix}-${!index}"}`)
+	s.NoError(err)
+	s.Equal("vol-3-pool-${index}", value)
+	value, err = s.getValue(parser.ValueTypeString,
+		`{"Sub": ["${name}:${host_ids}", {"name": {"Ref": "Prefix"}}]}`)
+	s.NoError(err)
+	s.Equal("pool:1,2", value)
+
+	expr := new(parser.StringExpr)
+	s.NoError(json.Unmarshal([]byte(`{"Sub": ["${name}-${volume}", {"name": {"Ref": "Prefix"}}]}`), expr))
+	s.Equal([]string{"Prefix", "volume"}, expr.References())
+	s.False(expr.IsReady(s.stack))
+	_, err = parser.GetExprValue(s.stack, expr)
+	s.Error(err)
+}
+
+func (s *parserFuncSuite) TestJoinSplitConcat() {
+	s.stack.resourceValueMap = map[string]interface{}{
+		"admin_ips": []string{"10.0.0.1", "10.0.0.2"},
+		"host_ids":  []int64{1, 2},
+	}
+
+	value, err := s.getValue(parser.ValueTypeString, `{"Join": [",", {"Ref": "admin_ips"}]}`)
+	s.NoError(err)
+	s.Equal("10.0.0.1,10.0.0.2", value)
+	value, err = s.getValue(parser.ValueTypeString, `{"Join": ["-", {"Ref": "host_ids"}]}`)
+	s.NoError(err)
+	s.Equal("1-2", value)
+	value, err = s.getValue(parser.ValueTypeString, `{"Join": [" ", ["a", "b"]]}`)
+	s.NoError(err)
+	s.Equal("a b", value)
+
+	value, err = s.getValue(parser.ValueTypeStringList, `{"Split": [",", "a,b"]}`)
+	s.NoError(err)
+	s.Equal([]string{"a", "b"}, value)
+	value, err = s.getValue(parser.ValueTypeIntegerList, `{"Split": [",", "1, 2"]}`)
+	s.NoError(err)
+	s.Equal([]int64{1, 2}, value)
+	_, err = s.getValue(parser.ValueTypeIntegerList, `{"Split": [",", "1,a"]}`)
+	s.Error(err)
+
+	value, err = s.getValue(parser.ValueTypeString,
+		`{"Concat": ["pool-", {"Select": [1, {"Ref": "admin_ips"}]}, "-", {"Ref": "host_ids"}]}`)
+	s.NoError(err)
+	s.Equal("pool-10.0.0.2-1,2", value)
+	value, err = s.getValue(parser.ValueTypeStringList, `{"Concat": [{"Ref": "admin_ips"}, ["10.0.0.3"]]}`)
+	s.NoError(err)
+	s.Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, value)
+	value, err = s.getValue(parser.ValueTypeIntegerList, `{"Concat": [{"Ref": "host_ids"}, [3]]}`)
+	s.NoError(err)
+	s.Equal([]int64{1, 2, 3}, value)
+}
+
+func (s *parserFuncSuite) TestConditions() {
+	s.stack.resourceValueMap = map[string]interface{}{"disk_type": "SSD", "replica": int64(3)}
+	s.stack.template = &Template{Conditions: map[string]*parser.BoolExpr{}}
+	for name, data := range map[string]string{
+		"WithSSD":   `{"Equals": [{"Ref": "disk_type"}, "SSD"]}`,
+		"Replica3":  `{"Equals": [{"Ref": "replica"}, 3]}`,
+		"SSDAndHDD": `{"And": [{"Condition": "WithSSD"}, {"Not": {"Condition": "WithSSD"}}]}`,
+		"SSDOrHDD":  `{"Or": [{"Condition": "SSDAndHDD"}, {"Condition": "Replica3"}]}`,
+		"Loop":      `{"Not": {"Condition": "Loop"}}`,
+	} {
+		expr := new(parser.BoolExpr)
+		s.NoError(json.Unmarshal([]byte(data), expr), name)
+		s.stack.template.Conditions[name] = expr
+	}
+	s.Error(s.stack.evaluateConditions())
+
+	delete(s.stack.template.Conditions, "Loop")
+	s.NoError(s.stack.evaluateConditions())
+	s.Equal(map[string]bool{"WithSSD": true, "Replica3": true, "SSDAndHDD": false, "SSDOrHDD": true},
+		s.stack.conditionValues)
+	_, err := s.stack.GetCondition("NotExist")
+	s.Error(err)
+
+	value, err := s.getValue(parser.ValueTypeString, `{"If": ["WithSSD", "SSD", "HDD"]}`)
+	s.NoError(err)
+	s.Equal("SSD", value)
+	value, err = s.getValue(parser.ValueTypeInteger, `{"If": ["SSDAndHDD", 1, {"Ref": "replica"}]}`)
+	s.NoError(err)
+	s.Equal(int64(3), value)
+	_, err = s.getValue(parser.ValueTypeInteger, `{"Equals": [1, 1]}`)
+	s.Error(err)
+}
+
+func (s *parserFuncSuite) TestIntegerFuncs() {
+	s.stack.resourceValueMap = map[string]interface{}{
+		"host_ids":  []int64{1, 2, 3},
+		"admin_ips": []string{"10.0.0.1", "10.0.0.2"},
+		"replica":   int64(3),
+	}
+
+	value, err := s.getValue(parser.ValueTypeInteger, `{"Add": [{"Ref": "replica"}, 1]}`)
+	s.NoError(err)
+	s.Equal(int64(4), value)
+	value, err = s.getValue(parser.ValueTypeInteger, `{"Sum": {"Ref": "host_ids"}}`)
+	s.NoError(err)
+	s.Equal(int64(6), value)
+	value, err = s.getValue(parser.ValueTypeInteger, `{"Multiply": [{"Length": {"Ref": "admin_ips"}}, 4]}`)
+	s.NoError(err)
+	s.Equal(int64(8), value)
+	value, err = s.getValue(parser.ValueTypeInteger, `{"Divide": [{"Ref": "replica"}, 2]}`)
+	s.NoError(err)
+	s.Equal(int64(1), value)
+	_, err = s.getValue(parser.ValueTypeInteger, `{"Divide": [1, 0]}`)
+	s.Error(err)
+
+	value, err = s.getValue(parser.ValueTypeInteger, `{"Length": {"Ref": "host_ids"}}`)
+	s.NoError(err)
+	s.Equal(int64(3), value)
+	value, err = s.getValue(parser.ValueTypeInteger, `{"Length": [1, 2]}`)
+	s.NoError(err)
+	s.Equal(int64(2), value)
+	value, err = s.getValue(parser.ValueTypeInteger, `{"Length": {"Split": [",", "a,b,c"]}}`)
+	s.NoError(err)
+	s.Equal(int64(3), value)
+	_, err = s.getValue(parser.ValueTypeString, `{"Length": [1, 2]}`)
+	s.Error(err)
+}
+
+func (s *parserFuncSuite) TestListFuncs() {
+	s.stack.resourceValueMap = map[string]interface{}{
+		"host_ids":  []int64{1, 2, 3},
+		"admin_ips": []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"},
+	}
+
+	value, err := s.getValue(parser.ValueTypeIntegerList, `{"Range": 3}`)
+	s.NoError(err)
+	s.Equal([]int64{0, 1, 2}, value)
+	value, err = s.getValue(parser.ValueTypeIntegerList, `{"Range": [1, 7, 2]}`)
+	s.NoError(err)
+	s.Equal([]int64{1, 3, 5}, value)
+	value, err = s.getValue(parser.ValueTypeIntegerList, `{"Range": [3, 0, -1]}`)
+	s.NoError(err)
+	s.Equal([]int64{3, 2, 1}, value)
+	_, err = s.getValue(parser.ValueTypeIntegerList, `{"Range": [0, 3, 0]}`)
+	s.Error(err)
+
+	value, err = s.getValue(parser.ValueTypeIntegerList, `{"Slice": [1, 3, {"Ref": "host_ids"}]}`)
+	s.NoError(err)
+	s.Equal([]int64{2, 3}, value)
+	value, err = s.getValue(parser.ValueTypeStringList, `{"Slice": [0, 1, {"Ref": "admin_ips"}]}`)
+	s.NoError(err)
+	s.Equal([]string{"10.0.0.1"}, value)
+	_, err = s.getValue(parser.ValueTypeIntegerList, `{"Slice": [2, 4, {"Ref": "host_ids"}]}`)
+	s.Error(err)
+
+	value, err = s.getValue(parser.ValueTypeStringList, `{"Unique": {"Ref": "admin_ips"}}`)
+	s.NoError(err)
+	s.Equal([]string{"10.0.0.1", "10.0.0.2"}, value)
+	value, err = s.getValue(parser.ValueTypeIntegerList, `{"Unique": [3, 1, 3, 2, 1]}`)
+	s.NoError(err)
+	s.Equal([]int64{3, 1, 2}, value)
+
+	value, err = s.getValue(parser.ValueTypeIntegerList, `{"Flatten": [[0], {"Ref": "host_ids"}, [4, [5, 6]]]}`)
+	s.NoError(err)
+	s.Equal([]int64{0, 1, 2, 3, 4, 5, 6}, value)
+	value, err = s.getValue(parser.ValueTypeStringList, `{"Flatten": [["a"], [["b"], "c"]]}`)
+	s.NoError(err)
+	s.Equal([]string{"a", "b", "c"}, value)
+}
+
+func (s *parserFuncSuite) TestBoolList() {
+	s.stack.resourceValueMap = map[string]interface{}{"crypto": []bool{true, false}, "compress": true}
+
+	value, err := s.getValue(parser.ValueTypeBoolList, `[{"Ref": "compress"}, "false"]`)
+	s.NoError(err)
+	s.Equal([]bool{true, false}, value)
+	value, err = s.getValue(parser.ValueTypeBool, `{"Select": [1, {"Ref": "crypto"}]}`)
+	s.NoError(err)
+	s.Equal(false, value)
+	value, err = s.getValue(parser.ValueTypeBool, `{"Select": [0, [false, true]]}`)
+	s.NoError(err)
+	s.Equal(false, value)
+	_, err = s.getValue(parser.ValueTypeBool, `{"Select": [2, {"Ref": "crypto"}]}`)
+	s.Error(err)
+	value, err = s.getValue(parser.ValueTypeBoolList, `{"Ref": "compress"}`)
+	s.NoError(err)
+	s.Equal([]bool{true}, value)
+	value, err = s.getValue(parser.ValueTypeBoolList, `{"Unique": [true, true, false]}`)
+	s.NoError(err)
+	s.Equal([]bool{true, false}, value)
+}
+
+func (s *parserFuncSuite) TestFindInMap() {
+	s.stack.resourceValueMap = map[string]interface{}{"site": "dc1"}
+	s.stack.template = new(Template)
+	s.NoError(json.Unmarshal([]byte(`{"Mappings": {"SiteConfig": {
+		"dc1": {"cidr": "10.0.0.0/24", "replica": 3, "ssd": true, "host_ids": [1, 2]},
+		"dc2": {"cidr": "10.0.1.0/24", "replica": 2, "ssd": false, "host_ids": [3]}
+	}}}`), s.stack.template))
+
+	value, err := s.getValue(parser.ValueTypeString, `{"FindInMap": ["SiteConfig", {"Ref": "site"}, "cidr"]}`)
+	s.NoError(err)
+	s.Equal("10.0.0.0/24", value)
+	value, err = s.getValue(parser.ValueTypeInteger, `{"FindInMap": ["SiteConfig", "dc2", "replica"]}`)
+	s.NoError(err)
+	s.Equal(int64(2), value)
+	value, err = s.getValue(parser.ValueTypeBool, `{"FindInMap": ["SiteConfig", "dc2", "ssd"]}`)
+	s.NoError(err)
+	s.Equal(false, value)
+	value, err = s.getValue(parser.ValueTypeIntegerList, `{"FindInMap": ["SiteConfig", "dc1", "host_ids"]}`)
+	s.NoError(err)
+	s.Equal([]int64{1, 2}, value)
+
+	_, err = s.getValue(parser.ValueTypeString, `{"FindInMap": ["SiteConfig", "dc3", "cidr"]}`)
+	s.True(errors.IsNotFound(errors.Cause(err)))
+	_, err = s.getValue(parser.ValueTypeInteger, `{"FindInMap": ["SiteConfig", "dc1", "cidr"]}`)
+	s.Error(err)
+	_, err = s.getValue(parser.ValueTypeString, `{"FindInMap": ["SiteConfig", "dc1"]}`)
+	s.Error(err)
+}
+
+func (s *parserFuncSuite) TestTypedReferences() {
+	expr := new(parser.IntegerListExpr)
+	s.NoError(json.Unmarshal([]byte(`{"Concat": [{"Ref": "host_ids"},
+		[{"Select": [0, {"Ref": "osd_ids"}]}, {"Length": {"Ref": "names"}}]]}`), expr))
+	s.Equal([]parser.TypedReference{
+		{Name: "host_ids", ValueType: parser.ValueTypeIntegerList},
+		{Name: "osd_ids", ValueType: parser.ValueTypeIntegerList},
+		{Name: "names"},
+	}, parser.TypedReferences(expr))
+
+	// values of any type could be formatted as strings
+	stringExpr := new(parser.StringExpr)
+	s.NoError(json.Unmarshal([]byte(`{"Sub": ["${name}", {"name": {"Ref": "size"}}]}`), stringExpr))
+	s.Equal([]parser.TypedReference{{Name: "size"}}, parser.TypedReferences(stringExpr))
+}
+
+func TestParserFuncSuite(t *testing.T) {
+	suite.Run(t, new(parserFuncSuite))
+}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -22,7 +23,7 @@ type AccessPath struct {
 	Name               *parser.StringExpr
 	ProtectionDomainID *parser.IntegerExpr
 	Tname              *parser.StringExpr
-	Tsecret            *parser.StringExpr
+	Tsecret            *parser.StringExpr `sensitive:"true"`
 	Type               *parser.StringExpr
 }
 
@@ -96,7 +97,7 @@ func (accessPath *AccessPath) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (accessPath *AccessPath) Create() (created bool, err error) {
+func (accessPath *AccessPath) Create(ctx context.Context) (created bool, err error) {
 	if accessPath.Name == nil {
 		err = fmt.Errorf("Name is required for resource %s", accessPath.GetType())
 		return
@@ -106,7 +107,7 @@ func (accessPath *AccessPath) Create() (created bool, err error) {
 	}
 
 	name := accessPath.getStringValue(accessPath.Name)
-	resourceID, err := accessPath.getResourceByName(name)
+	resourceID, err := accessPath.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -151,7 +152,7 @@ func (accessPath *AccessPath) Create() (created bool, err error) {
 		req.AccessPath.MappingGroups = append(req.AccessPath.MappingGroups, *mappingGroupReq)
 	}
 
-	body, err := accessPath.CallCreateAPI(req, nil)
+	body, err := accessPath.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create access path %s", name)
 	}
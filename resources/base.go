@@ -1,15 +1,20 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/rand"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/juju/errors"
 
+	"xsky.com/sds-formation/config"
+	openapiClient "xsky.com/sds-formation/openapi-client"
 	"xsky.com/sds-formation/parser"
 	"xsky.com/sds-formation/utils"
 )
@@ -29,25 +34,91 @@ type ResourceBase struct {
 	delegate utils.ResourceInterface
 
 	recordInstance reflect.Type
+
+	deletingIdentifies []string
+	plannedRequests    []*utils.PlannedRequest
+
+	// name and query params of the last lookup by getResourceByName, which are used to
+	// check whether the resource is created by a failed create call
+	lookupName   *string
+	lookupParams map[string]string
+	// createCalled is set once create api is called, resources found before that existed
+	// before the stack and are adopted
+	createCalled bool
+	adopted      bool
+	// identifies of existing items adopted by a list resource which is partly created
+	adoptedItems []string
 }
 
 // CallResourceAPI call resource api
-func (r *ResourceBase) CallResourceAPI(apiType string, req interface{}, pathParam map[string]string,
-	queryParam ...map[string]string) ([]byte, error) {
+func (r *ResourceBase) CallResourceAPI(ctx context.Context, apiType string, req interface{},
+	pathParam map[string]string, queryParam ...map[string]string) ([]byte, error) {
 
 	api, err := settings.GetSetting(r.GetType(), apiType)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	body, err := r.stack.CallAPI(api, req, pathParam, queryParam...)
+	if config.Plan && r.isChangingAPI(apiType) {
+		body, err := r.planAPI(api, req, pathParam)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return body, nil
+	}
+	body, err := r.stack.CallAPI(ctx, api, req, pathParam, queryParam...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return body, nil
+}
+
+func (r *ResourceBase) isChangingAPI(apiType string) bool {
+	// token is always created since it's required by getting resources from server
+	if r.GetType() == utils.ResourceToken {
+		return false
+	}
+	return apiType == utils.CreateAPIName || apiType == utils.UpdateAPIName ||
+		apiType == utils.DeleteAPIName
+}
+
+// planAPI records the request and returns a faked response of an active instance
+func (r *ResourceBase) planAPI(api string, req interface{}, pathParam map[string]string) ([]byte, error) {
+	plannedRequest := &utils.PlannedRequest{API: api, PathParams: pathParam}
+	if req != nil {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		plannedRequest.Body = body
+	}
+	r.plannedRequests = append(r.plannedRequests, plannedRequest)
+
+	// record key could not exist if response is not used
+	recordKey, _ := settings.GetSetting(r.GetType(), utils.RecordKey)
+	if recordKey == "" {
+		return []byte("{}"), nil
+	}
+	resp := map[string]interface{}{
+		recordKey: map[string]interface{}{
+			"id":            rand.Int63(),
+			"status":        utils.StatusActive,
+			"action_status": utils.StatusActive,
+		},
+	}
+	body, err := json.Marshal(resp)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 	return body, nil
 }
 
+// PlannedRequests returns requests recorded in plan mode
+func (r *ResourceBase) PlannedRequests() []*utils.PlannedRequest {
+	return r.plannedRequests
+}
+
 // CallGetAPI calls get api of resource instance
-func (r *ResourceBase) CallGetAPI(pathParams ...map[string]string) ([]byte, error) {
+func (r *ResourceBase) CallGetAPI(ctx context.Context, pathParams ...map[string]string) ([]byte, error) {
 	pathParam := map[string]string{}
 	// get req identify key could not exist
 	getReqIdentify, _ := settings.GetSetting(r.GetType(), utils.GetReqIdentify)
@@ -64,16 +135,80 @@ func (r *ResourceBase) CallGetAPI(pathParams ...map[string]string) ([]byte, erro
 		}
 	}
 
-	body, err := r.CallResourceAPI(utils.GetAPIName, nil, pathParam)
+	body, err := r.CallResourceAPI(ctx, utils.GetAPIName, nil, pathParam)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 	return body, nil
 }
 
-// CallCreateAPI call create api of resource
-func (r *ResourceBase) CallCreateAPI(req interface{}, pathParam map[string]string, queryParam ...map[string]string) ([]byte, error) {
-	body, err := r.CallResourceAPI(utils.CreateAPIName, req, pathParam, queryParam...)
+// CallCreateAPI call create api of resource, the call is retried on failure only if the
+// resource has been looked up by name, so that it could be checked not created before retry
+func (r *ResourceBase) CallCreateAPI(ctx context.Context, req interface{}, pathParam map[string]string,
+	queryParam ...map[string]string) ([]byte, error) {
+
+	if config.Plan && r.isChangingAPI(utils.CreateAPIName) {
+		body, err := r.CallResourceAPI(ctx, utils.CreateAPIName, req, pathParam, queryParam...)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return body, nil
+	}
+
+	api, err := settings.GetSetting(r.GetType(), utils.CreateAPIName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r.createCalled = true
+	var check openapiClient.CreatedCheck
+	if r.lookupName != nil {
+		check = r.checkCreated
+	}
+	body, err := r.stack.GetOpenAPIClient().CallCreateAPI(ctx, api, req, pathParam, check, queryParam...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return body, nil
+}
+
+// checkCreated looks up the resource by name again, and returns body of get api of it if
+// it's found
+func (r *ResourceBase) checkCreated(ctx context.Context) ([]byte, error) {
+	params := map[string]string{}
+	for key, val := range r.lookupParams {
+		params[key] = val
+	}
+	id, err := r.getResourceByName(ctx, *r.lookupName, params)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if id == nil {
+		return nil, nil
+	}
+	// resource without get api can't be checked
+	getReqIdentify, _ := settings.GetSetting(r.GetType(), utils.GetReqIdentify)
+	if getReqIdentify == "" {
+		return nil, errors.Errorf("get api of resource %s not found", r.GetType())
+	}
+	identify, err := r.getValString(id)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	body, err := r.CallGetAPI(ctx, map[string]string{getReqIdentify: identify})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return body, nil
+}
+
+// CallDeleteAPI call delete api of resource instance with identify
+func (r *ResourceBase) CallDeleteAPI(ctx context.Context, identify string) ([]byte, error) {
+	getReqIdentify, err := settings.GetSetting(r.GetType(), utils.GetReqIdentify)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	pathParam := map[string]string{getReqIdentify: identify}
+	body, err := r.CallResourceAPI(ctx, utils.DeleteAPIName, nil, pathParam)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -87,15 +222,27 @@ func (r *ResourceBase) isReady(expr parser.ExprType) (ready bool) {
 	return expr.IsReady(r.stack)
 }
 
-func (r *ResourceBase) getResourceByName(name string, queryParams ...map[string]string) (interface{}, error) {
-	id, err := r.getResourceFromListAPI("Name", name, queryParams...)
+func (r *ResourceBase) getResourceByName(ctx context.Context, name string,
+	queryParams ...map[string]string) (interface{}, error) {
+
+	r.lookupName = &name
+	r.lookupParams = map[string]string{}
+	if len(queryParams) != 0 {
+		for key, val := range queryParams[0] {
+			r.lookupParams[key] = val
+		}
+	}
+	id, err := r.getResourceFromListAPI(ctx, "Name", name, queryParams...)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if id != nil && !r.createCalled {
+		r.adopted = true
+	}
 	return id, nil
 }
 
-func (r *ResourceBase) getResourceFromListAPI(field string, val interface{},
+func (r *ResourceBase) getResourceFromListAPI(ctx context.Context, field string, val interface{},
 	queryParams ...map[string]string) (resourceID interface{}, err error) {
 
 	if len(queryParams) == 0 {
@@ -107,7 +254,7 @@ func (r *ResourceBase) getResourceFromListAPI(field string, val interface{},
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	body, err := r.stack.GetOpenAPIClient().CallAPI(apiName, nil, nil, queryParams...)
+	body, err := r.stack.GetOpenAPIClient().CallAPI(ctx, apiName, nil, nil, queryParams...)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to list resource")
 	}
@@ -223,6 +370,126 @@ func (r *ResourceBase) Repr() interface{} {
 	return r.repr
 }
 
+// ResolveProperties returns values of properties set for the resource, values of sensitive
+// properties are registered as secrets and redacted
+func (r *ResourceBase) ResolveProperties() (map[string]interface{}, error) {
+	if r.delegate == nil {
+		return nil, nil
+	}
+	properties, err := r.resolveStruct(reflect.Indirect(reflect.ValueOf(r.delegate)))
+	if err != nil {
+		return nil, errors.Annotatef(err, "resolve properties of %s", r.GetType())
+	}
+	return properties, nil
+}
+
+func (r *ResourceBase) resolveStruct(structVal reflect.Value) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	structType := structVal.Type()
+	for i := 0; i < structVal.NumField(); i++ {
+		field := structType.Field(i)
+		// skip ResourceBase and private fields
+		if field.Anonymous || field.PkgPath != "" {
+			continue
+		}
+		value, err := r.resolveValue(structVal.Field(i))
+		if err != nil {
+			return nil, errors.Annotatef(err, "property %s", field.Name)
+		}
+		// sensitive values are redacted in logs and never recorded
+		if value != nil && field.Tag.Get("sensitive") == "true" {
+			if secret, ok := value.(string); ok {
+				utils.AddSecret(secret)
+			}
+			value = utils.RedactedValue
+		}
+		if value != nil {
+			properties[field.Name] = value
+		}
+	}
+	return properties, nil
+}
+
+func (r *ResourceBase) resolveValue(val reflect.Value) (interface{}, error) {
+	if (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) && val.IsNil() {
+		return nil, nil
+	}
+	if expr, ok := val.Interface().(parser.ExprType); ok {
+		value, err := parser.GetExprValue(r.stack, expr)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return value, nil
+	}
+	val = reflect.Indirect(val)
+	switch val.Kind() {
+	case reflect.Struct:
+		return r.resolveStruct(val)
+	case reflect.Slice:
+		values := make([]interface{}, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			value, err := r.resolveValue(val.Index(i))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+	return nil, nil
+}
+
+// References returns names of parameters and resources referenced by properties of the resource
+func (r *ResourceBase) References() []string {
+	if r.delegate == nil {
+		return nil
+	}
+	return r.structReferences(reflect.Indirect(reflect.ValueOf(r.delegate)))
+}
+
+func (r *ResourceBase) structReferences(structVal reflect.Value) (names []string) {
+	structType := structVal.Type()
+	for i := 0; i < structVal.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous || field.PkgPath != "" {
+			continue
+		}
+		names = append(names, r.valueReferences(structVal.Field(i))...)
+	}
+	return names
+}
+
+func (r *ResourceBase) valueReferences(val reflect.Value) (names []string) {
+	if (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) && val.IsNil() {
+		return nil
+	}
+	if expr, ok := val.Interface().(parser.ExprType); ok {
+		return expr.References()
+	}
+	val = reflect.Indirect(val)
+	switch val.Kind() {
+	case reflect.Struct:
+		return r.structReferences(val)
+	case reflect.Slice:
+		for i := 0; i < val.Len(); i++ {
+			names = append(names, r.valueReferences(val.Index(i))...)
+		}
+	}
+	return names
+}
+
+// Adopted returns whether the resource existed before and is reused instead of being created,
+// adopted resources are not deleted when the stack is destroyed
+func (r *ResourceBase) Adopted() bool {
+	return r.adopted
+}
+
+// AdoptedItems returns identifies of existing items adopted by a list resource whose other
+// items are created, they're not deleted with the created ones
+func (r *ResourceBase) AdoptedItems() []string {
+	return r.adoptedItems
+}
+
 // CheckInterval return check interval
 func (r *ResourceBase) CheckInterval() int {
 	return utils.DefaultCheckInterval
@@ -234,33 +501,90 @@ func (r *ResourceBase) IsReady() bool {
 }
 
 // Get get the resource
-func (r *ResourceBase) Get() (err error) {
+func (r *ResourceBase) Get(ctx context.Context) (err error) {
 	return errors.Errorf("Not implemented")
 }
 
 // Create create the resource
-func (r *ResourceBase) Create() (created bool, err error) {
+func (r *ResourceBase) Create(ctx context.Context) (created bool, err error) {
 	return false, errors.Errorf("Not implemented")
 }
 
 // Update update a resource
-func (r *ResourceBase) Update(repr interface{}) (updated bool, err error) {
+func (r *ResourceBase) Update(ctx context.Context, repr interface{}) (updated bool, err error) {
 	return false, errors.Errorf("Not implemented")
 }
 
 // IsUpdated check if a resource is updated
-func (r *ResourceBase) IsUpdated() (updated bool, err error) {
+func (r *ResourceBase) IsUpdated(ctx context.Context) (updated bool, err error) {
 	return false, errors.Errorf("Not implemented")
 }
 
-// Delete delete a resource
-func (r *ResourceBase) Delete(repr interface{}) (deleted bool, err error) {
-	return false, errors.Errorf("Not implemented")
+// Delete delete a resource, repr could be identify of a resource or a list of identifies
+// of the same kind of resources
+func (r *ResourceBase) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	r.repr = repr
+	if config.DryRun {
+		return true, nil
+	}
+
+	identifies, err := r.getIdentifies(repr)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	for _, identify := range identifies {
+		if _, err = r.CallDeleteAPI(ctx, identify); err != nil && !openapiClient.IsNotFound(err) {
+			return false, errors.Annotatef(err, "delete %s %s", r.GetType(), identify)
+		}
+	}
+	r.deletingIdentifies = identifies
+	return false, nil
 }
 
 // IsDeleted check if a resource is deleted
-func (r *ResourceBase) IsDeleted() (deleted bool, err error) {
-	return false, errors.Errorf("Not implemented")
+func (r *ResourceBase) IsDeleted(ctx context.Context) (deleted bool, err error) {
+	getReqIdentify, err := settings.GetSetting(r.GetType(), utils.GetReqIdentify)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	deletingIdentifies := []string{}
+	for _, identify := range r.deletingIdentifies {
+		_, err := r.CallGetAPI(ctx, map[string]string{getReqIdentify: identify})
+		if err == nil {
+			deletingIdentifies = append(deletingIdentifies, identify)
+			continue
+		}
+		if !openapiClient.IsNotFound(err) {
+			return false, errors.Annotatef(err, "get %s %s", r.GetType(), identify)
+		}
+		log.Printf("item %s %s is deleted", r.GetType(), identify)
+	}
+
+	r.deletingIdentifies = deletingIdentifies
+	return len(deletingIdentifies) == 0, nil
+}
+
+func (r *ResourceBase) getIdentifies(repr interface{}) ([]string, error) {
+	if repr == nil {
+		return nil, nil
+	}
+	reprVal := reflect.ValueOf(repr)
+	if reprVal.Kind() != reflect.Slice {
+		identify, err := r.getValString(repr)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return []string{identify}, nil
+	}
+	identifies := make([]string, 0, reprVal.Len())
+	for i := 0; i < reprVal.Len(); i++ {
+		identify, err := r.getValString(reprVal.Index(i).Interface())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		identifies = append(identifies, identify)
+	}
+	return identifies, nil
 }
 
 // GetType calls GetType of real resource instance
@@ -331,8 +655,8 @@ func (r *ResourceBase) getValString(val interface{}) (string, error) {
 }
 
 // IsCreated if the resource has been created
-func (r *ResourceBase) IsCreated() (created bool, err error) {
-	body, err := r.CallGetAPI(nil)
+func (r *ResourceBase) IsCreated(ctx context.Context) (created bool, err error) {
+	body, err := r.CallGetAPI(ctx, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "get resource with id %d", r.repr)
 	}
@@ -343,93 +667,106 @@ func (r *ResourceBase) IsCreated() (created bool, err error) {
 	return r.checkStatus(status)
 }
 
-// NewResource returns a new resource object correspoding with the provided type
-func NewResource(typeName string, action string) utils.ResourceInterface {
-	switch typeName {
-	case utils.ResourceIntegerList:
-		return &IntegerList{}
-	case utils.ResourceStringList:
-		return &StringList{}
-	case utils.ResourceHost:
-		return &Host{}
-	case utils.ResourceHosts:
-		return &Hosts{}
-	case utils.ResourceBlockVolume:
-		return &BlockVolume{}
-	case utils.ResourceBlockVolumes:
-		return &BlockVolumes{}
-	case utils.ResourceDiskList:
-		if action == utils.ActionTypeUpdate {
-			return &DiskListUpdate{}
+// IsCreatable returns if resource of the type could be created on server, resources
+// like DiskList only query or calculate values
+func IsCreatable(typeName string) bool {
+	_, err := settings.GetSetting(typeName, utils.CreateAPIName)
+	return err == nil
+}
+
+// APIs returns operation ids of apis called by resources of the type
+func APIs(typeName string) []string {
+	var apis []string
+	for _, key := range []string{utils.ListAPIName, utils.GetAPIName, utils.CreateAPIName,
+		utils.UpdateAPIName, utils.DeleteAPIName} {
+
+		if api, ok := settings[typeName][key]; ok {
+			apis = append(apis, api)
 		}
-		return &DiskList{}
-	case utils.ResourceOsd:
-		return &Osd{}
-	case utils.ResourceOsds:
-		return &Osds{}
-	case utils.ResourcePool:
-		return &Pool{}
-	case utils.ResourcePartitions:
-		return &Partitions{}
-	case utils.ResourceUser:
-		return &User{}
-	case utils.ResourceBootNode:
-		return &BootNode{}
-	case utils.ResourceObjectStorage:
-		return &ObjectStorage{}
-	case utils.ResourceObjectStorageUser:
-		return &ObjectStorageUser{}
-	case utils.ResourceObjectStoragePolicy:
-		return &ObjectStoragePolicy{}
-	case utils.ResourceObjectStorageBucket:
-		return &ObjectStorageBucket{}
-	case utils.ResourceObjectStorageGateway:
-		return &ObjectStorageGateway{}
-	case utils.ResourceNFSGateway:
-		return &NFSGateway{}
-	case utils.ResourceObjectStorageArchivePool:
-		return &ObjectStorageArchivePool{}
-	case utils.ResourceClientGroup:
-		return &ClientGroup{}
-	case utils.ResourceAccessPath:
-		return &AccessPath{}
-	case utils.ResourceMappingGroup:
-		return &MappingGroup{}
-	case utils.ResourceToken:
-		return &Token{}
-	case utils.ResourceS3LoadBalancerGroup:
-		return &S3LoadBalancerGroup{}
-	case utils.ResourceNetworkAddress:
-		return &NetworkAddress{}
-	case utils.ResourceFSAD:
-		return &FSAD{}
-	case utils.ResourceFSClient:
-		return &FSClient{}
-	case utils.ResourceFSClientGroup:
-		return &FSClientGroup{}
-	case utils.ResourceFSFTPShare:
-		return &FSFTPShare{}
-	case utils.ResourceFSFolder:
-		return &FSFolder{}
-	case utils.ResourceFSGatewayGroup:
-		return &FSGatewayGroup{}
-	case utils.ResourceFSLdap:
-		return &FSLdap{}
-	case utils.ResourceFSNFSShare:
-		return &FSNFSShare{}
-	case utils.ResourceFSQuotaTree:
-		return &FSFolderQuotaTree{}
-	case utils.ResourceFSSMBShare:
-		return &FSSMBShare{}
-	case utils.ResourceFSUser:
-		return &FSUser{}
-	case utils.ResourceFSUserGroup:
-		return &FSUserGroup{}
-	case utils.ResourceFSArbitrationPool:
-		return &FSArbitrationPool{}
-	case utils.ResourceTemplate:
-		return &ResourceBase{}
 	}
+	return apis
+}
+
+// ValueType returns type of value of resource of the type, which is id of the resource
+// or ids of resources of a list, empty string is returned if value of the type can't be
+// referenced as an expression, e.g. values of template resource
+func ValueType(typeName string) string {
+	switch typeName {
+	case utils.ResourceTemplate:
+		return ""
+	case utils.ResourceToken:
+		return parser.ValueTypeString
+	case utils.ResourceStringList:
+		return parser.ValueTypeStringList
+	case utils.ResourceIntegerList, utils.ResourceHosts, utils.ResourceOsds,
+		utils.ResourceBlockVolumes, utils.ResourcePartitions, utils.ResourceDiskList:
+		return parser.ValueTypeIntegerList
+	}
+	return parser.ValueTypeInteger
+}
+
+// resourceTypes are constructors of resources by type names
+var resourceTypes = map[string]func() utils.ResourceInterface{
+	utils.ResourceIntegerList:              func() utils.ResourceInterface { return &IntegerList{} },
+	utils.ResourceStringList:               func() utils.ResourceInterface { return &StringList{} },
+	utils.ResourceHost:                     func() utils.ResourceInterface { return &Host{} },
+	utils.ResourceHosts:                    func() utils.ResourceInterface { return &Hosts{} },
+	utils.ResourceBlockVolume:              func() utils.ResourceInterface { return &BlockVolume{} },
+	utils.ResourceBlockVolumes:             func() utils.ResourceInterface { return &BlockVolumes{} },
+	utils.ResourceDiskList:                 func() utils.ResourceInterface { return &DiskList{} },
+	utils.ResourceOsd:                      func() utils.ResourceInterface { return &Osd{} },
+	utils.ResourceOsds:                     func() utils.ResourceInterface { return &Osds{} },
+	utils.ResourcePool:                     func() utils.ResourceInterface { return &Pool{} },
+	utils.ResourcePartitions:               func() utils.ResourceInterface { return &Partitions{} },
+	utils.ResourceUser:                     func() utils.ResourceInterface { return &User{} },
+	utils.ResourceBootNode:                 func() utils.ResourceInterface { return &BootNode{} },
+	utils.ResourceObjectStorage:            func() utils.ResourceInterface { return &ObjectStorage{} },
+	utils.ResourceObjectStorageUser:        func() utils.ResourceInterface { return &ObjectStorageUser{} },
+	utils.ResourceObjectStoragePolicy:      func() utils.ResourceInterface { return &ObjectStoragePolicy{} },
+	utils.ResourceObjectStorageBucket:      func() utils.ResourceInterface { return &ObjectStorageBucket{} },
+	utils.ResourceObjectStorageGateway:     func() utils.ResourceInterface { return &ObjectStorageGateway{} },
+	utils.ResourceNFSGateway:               func() utils.ResourceInterface { return &NFSGateway{} },
+	utils.ResourceObjectStorageArchivePool: func() utils.ResourceInterface { return &ObjectStorageArchivePool{} },
+	utils.ResourceClientGroup:              func() utils.ResourceInterface { return &ClientGroup{} },
+	utils.ResourceAccessPath:               func() utils.ResourceInterface { return &AccessPath{} },
+	utils.ResourceMappingGroup:             func() utils.ResourceInterface { return &MappingGroup{} },
+	utils.ResourceToken:                    func() utils.ResourceInterface { return &Token{} },
+	utils.ResourceS3LoadBalancerGroup:      func() utils.ResourceInterface { return &S3LoadBalancerGroup{} },
+	utils.ResourceNetworkAddress:           func() utils.ResourceInterface { return &NetworkAddress{} },
+	utils.ResourceFSAD:                     func() utils.ResourceInterface { return &FSAD{} },
+	utils.ResourceFSClient:                 func() utils.ResourceInterface { return &FSClient{} },
+	utils.ResourceFSClientGroup:            func() utils.ResourceInterface { return &FSClientGroup{} },
+	utils.ResourceFSFTPShare:               func() utils.ResourceInterface { return &FSFTPShare{} },
+	utils.ResourceFSFolder:                 func() utils.ResourceInterface { return &FSFolder{} },
+	utils.ResourceFSGatewayGroup:           func() utils.ResourceInterface { return &FSGatewayGroup{} },
+	utils.ResourceFSLdap:                   func() utils.ResourceInterface { return &FSLdap{} },
+	utils.ResourceFSNFSShare:               func() utils.ResourceInterface { return &FSNFSShare{} },
+	utils.ResourceFSQuotaTree:              func() utils.ResourceInterface { return &FSFolderQuotaTree{} },
+	utils.ResourceFSSMBShare:               func() utils.ResourceInterface { return &FSSMBShare{} },
+	utils.ResourceFSUser:                   func() utils.ResourceInterface { return &FSUser{} },
+	utils.ResourceFSUserGroup:              func() utils.ResourceInterface { return &FSUserGroup{} },
+	utils.ResourceFSArbitrationPool:        func() utils.ResourceInterface { return &FSArbitrationPool{} },
+	utils.ResourceTemplate:                 func() utils.ResourceInterface { return &ResourceBase{} },
+}
+
+// ResourceTypes returns names of all resource types in order
+func ResourceTypes() []string {
+	names := make([]string, 0, len(resourceTypes))
+	for name := range resourceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-	return nil
+// NewResource returns a new resource object correspoding with the provided type
+func NewResource(typeName string, action string) utils.ResourceInterface {
+	if typeName == utils.ResourceDiskList && action == utils.ActionTypeUpdate {
+		return &DiskListUpdate{}
+	}
+	newResource, ok := resourceTypes[typeName]
+	if !ok {
+		return nil
+	}
+	return newResource()
 }
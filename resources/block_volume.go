@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -163,7 +164,7 @@ func (volume *BlockVolume) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (volume *BlockVolume) Create() (created bool, err error) {
+func (volume *BlockVolume) Create(ctx context.Context) (created bool, err error) {
 	if volume.Name == nil {
 		return false, errors.Errorf("Name is required for resource %s", volume.GetType())
 	}
@@ -172,7 +173,7 @@ func (volume *BlockVolume) Create() (created bool, err error) {
 	}
 
 	name := volume.getStringValue(volume.Name)
-	resourceID, err := volume.getResourceByName(name)
+	resourceID, err := volume.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Annotatef(err, "get volume %s", name)
 	}
@@ -228,7 +229,7 @@ func (volume *BlockVolume) Create() (created bool, err error) {
 		volumeInfo.Qos = qosReq
 	}
 
-	body, err := volume.CallCreateAPI(req, nil)
+	body, err := volume.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create volume %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -79,7 +80,7 @@ func (volumes *BlockVolumes) IsReady() (ready bool) {
 	return true
 }
 
-func (volumes *BlockVolumes) getNames() (names []string, err error) {
+func (volumes *BlockVolumes) getNames(ctx context.Context) (names []string, err error) {
 	names = []string{}
 	if volumes.Names != nil {
 		names = volumes.getStringListValue(volumes.Names)
@@ -108,8 +109,10 @@ func (volumes *BlockVolumes) fakeCreate(names []string) (bool, error) {
 	return true, nil
 }
 
-func (volumes *BlockVolumes) getResource(names []string) (volumeMap map[string]int64, err error) {
-	body, err := volumes.CallResourceAPI(utils.ListAPIName, nil, nil)
+func (volumes *BlockVolumes) getResource(ctx context.Context, names []string) (
+	volumeMap map[string]int64, err error) {
+
+	body, err := volumes.CallResourceAPI(ctx, utils.ListAPIName, nil, nil)
 	if err != nil {
 		return nil, errors.Annotatef(err, "list block volumes")
 	}
@@ -135,8 +138,8 @@ func (volumes *BlockVolumes) getResource(names []string) (volumeMap map[string]i
 }
 
 // Create create the resource
-func (volumes *BlockVolumes) Create() (created bool, err error) {
-	names, err := volumes.getNames()
+func (volumes *BlockVolumes) Create(ctx context.Context) (created bool, err error) {
+	names, err := volumes.getNames(ctx)
 	if err != nil {
 		err = errors.Annotate(err, "failed to generate names for block volumes")
 		return
@@ -145,7 +148,7 @@ func (volumes *BlockVolumes) Create() (created bool, err error) {
 		return volumes.fakeCreate(names)
 	}
 
-	volumeMap, err := volumes.getResource(names)
+	volumeMap, err := volumes.getResource(ctx, names)
 	if err != nil {
 		err = errors.Annotatef(err, "failed to get block volumes %+v", names)
 		return
@@ -197,15 +200,17 @@ func (volumes *BlockVolumes) Create() (created bool, err error) {
 		volumeInfo.Qos = qosReq
 	}
 
+	adoptedItems := []string{}
 	for _, name := range names {
 		volumeID, ok := volumeMap[name]
 		if ok {
 			volumeIDs = append(volumeIDs, volumeID)
+			adoptedItems = append(adoptedItems, fmt.Sprintf("%d", volumeID))
 			continue
 		}
 
 		volumeInfo.Name = name
-		body, err := volumes.CallCreateAPI(req, nil)
+		body, err := volumes.CallCreateAPI(ctx, req, nil)
 		if err != nil {
 			return false, errors.Annotatef(err, "create volume %s", name)
 		}
@@ -216,13 +221,19 @@ func (volumes *BlockVolumes) Create() (created bool, err error) {
 		volumeIDs = append(volumeIDs, id.(int64))
 	}
 
+	// existing volumes shouldn't be deleted with created ones
+	if len(adoptedItems) == len(volumeIDs) {
+		volumes.adopted = len(volumeIDs) != 0
+	} else {
+		volumes.adoptedItems = adoptedItems
+	}
 	volumes.repr = volumeIDs
 	volumes.creatingBlockVolumeIDs = volumeIDs
 	return false, nil
 }
 
 // IsCreated if the resource has been created
-func (volumes *BlockVolumes) IsCreated() (created bool, err error) {
+func (volumes *BlockVolumes) IsCreated(ctx context.Context) (created bool, err error) {
 	creatingBlockVolumeIDs := []int64{}
 	for _, volumeID := range volumes.creatingBlockVolumeIDs {
 		identifyKey, err := settings.GetSetting(volumes.GetType(), utils.GetReqIdentify)
@@ -230,7 +241,7 @@ func (volumes *BlockVolumes) IsCreated() (created bool, err error) {
 			return false, errors.Trace(err)
 		}
 		pathParam := map[string]string{identifyKey: fmt.Sprintf("%d", volumeID)}
-		body, err := volumes.CallGetAPI(pathParam)
+		body, err := volumes.CallGetAPI(ctx, pathParam)
 		if err != nil {
 			return false, errors.Annotatef(err, "get block volume")
 		}
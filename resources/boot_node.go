@@ -1,7 +1,9 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
+	"log"
 
 	"github.com/juju/errors"
 
@@ -79,7 +81,7 @@ func (bootNode *BootNode) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (bootNode *BootNode) Create() (created bool, err error) {
+func (bootNode *BootNode) Create(ctx context.Context) (created bool, err error) {
 	if config.DryRun {
 		return bootNode.fakeCreate()
 	}
@@ -102,7 +104,7 @@ func (bootNode *BootNode) Create() (created bool, err error) {
 		bootNodeInfo.InstallerPath = bootNode.getStringValue(bootNode.InstallerPath)
 	}
 
-	body, err := bootNode.CallCreateAPI(req, nil)
+	body, err := bootNode.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "failed to set boot node")
 	}
@@ -122,3 +124,10 @@ func (bootNode *BootNode) Create() (created bool, err error) {
 
 	return false, nil
 }
+
+// Delete delete the resource, deleting boot node is not supported and will be skipped
+func (bootNode *BootNode) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	bootNode.repr = repr
+	log.Printf("skip deleting boot node %v", repr)
+	return true, nil
+}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -82,7 +83,7 @@ func (clientGroup *ClientGroup) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (clientGroup *ClientGroup) Create() (created bool, err error) {
+func (clientGroup *ClientGroup) Create(ctx context.Context) (created bool, err error) {
 	if clientGroup.Name == nil {
 		err = fmt.Errorf("Name is required for resource %s", clientGroup.GetType())
 		return
@@ -92,7 +93,7 @@ func (clientGroup *ClientGroup) Create() (created bool, err error) {
 	}
 
 	name := clientGroup.getStringValue(clientGroup.Name)
-	resourceID, err := clientGroup.getResourceByName(name)
+	resourceID, err := clientGroup.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Annotatef(err, "check if client group %s exists", name)
 	}
@@ -121,7 +122,7 @@ func (clientGroup *ClientGroup) Create() (created bool, err error) {
 		req.ClientGroup.Clients = append(req.ClientGroup.Clients, *clientReq)
 	}
 
-	body, err := clientGroup.CallCreateAPI(req, nil)
+	body, err := clientGroup.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create client group %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"math/rand"
@@ -201,14 +202,14 @@ func (diskList *DiskList) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (diskList *DiskList) Create() (created bool, err error) {
+func (diskList *DiskList) Create(ctx context.Context) (created bool, err error) {
 	if config.DryRun {
 		return diskList.fakeCreate()
 	}
 
 	diskIDs := []int64{}
 	if diskList.HostIDs == nil {
-		diskIDs, err = diskList.getDiskIDs()
+		diskIDs, err = diskList.getDiskIDs(ctx)
 		if err != nil {
 			err = errors.Trace(err)
 			return
@@ -216,7 +217,7 @@ func (diskList *DiskList) Create() (created bool, err error) {
 	} else {
 		hostIDs := diskList.getIntegerListValue(diskList.HostIDs)
 		for _, hostID := range hostIDs {
-			ids, e := diskList.getDiskIDs(hostID)
+			ids, e := diskList.getDiskIDs(ctx, hostID)
 			if e != nil {
 				return false, e
 			}
@@ -236,7 +237,7 @@ func (diskList *DiskList) Create() (created bool, err error) {
 	return true, nil
 }
 
-func (diskList *DiskList) getDiskIDs(args ...int64) (diskIDs []int64, err error) {
+func (diskList *DiskList) getDiskIDs(ctx context.Context, args ...int64) (diskIDs []int64, err error) {
 	argMap := make(map[string]string)
 	if diskList.Used != nil {
 		used, err := diskList.getValString(diskList.getBoolValue(diskList.Used))
@@ -255,7 +256,7 @@ func (diskList *DiskList) getDiskIDs(args ...int64) (diskIDs []int64, err error)
 	// unlimit
 	argMap["limit"] = "-1"
 
-	body, err := diskList.CallResourceAPI(utils.ListAPIName, nil, nil, argMap)
+	body, err := diskList.CallResourceAPI(ctx, utils.ListAPIName, nil, nil, argMap)
 	if err != nil {
 		return nil, errors.Annotatef(err, "list disks")
 	}
@@ -319,3 +320,9 @@ func (diskList *DiskList) getDiskIDs(args ...int64) (diskIDs []int64, err error)
 	}
 	return diskIDs, nil
 }
+
+// Delete delete the resource, nothing to delete since disk list only queries existing disks
+func (diskList *DiskList) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	diskList.repr = repr
+	return true, nil
+}
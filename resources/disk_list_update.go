@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/juju/errors"
@@ -46,7 +47,7 @@ func (diskList *DiskListUpdate) IsReady() (ready bool) {
 }
 
 // Update update the resource
-func (diskList *DiskListUpdate) Update(repr interface{}) (updated bool, err error) {
+func (diskList *DiskListUpdate) Update(ctx context.Context, repr interface{}) (updated bool, err error) {
 	diskIDs, ok := repr.([]int64)
 	if !ok {
 		return false, errors.Errorf("unexpected repr!!!")
@@ -70,7 +71,7 @@ func (diskList *DiskListUpdate) Update(repr interface{}) (updated bool, err erro
 	}
 	for _, diskID := range diskIDs {
 		pathParam := map[string]string{reqIdentifyKey: fmt.Sprintf("%d", diskID)}
-		_, err := diskList.CallResourceAPI(utils.UpdateAPIName, req, pathParam)
+		_, err := diskList.CallResourceAPI(ctx, utils.UpdateAPIName, req, pathParam)
 		if err != nil {
 			return false, errors.Annotatef(err, "update disk %d", diskID)
 		}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -37,7 +38,7 @@ type FSAD struct {
 	Realm     *parser.StringExpr
 	IP        *parser.StringExpr
 	UserName  *parser.StringExpr
-	Password  *parser.StringExpr
+	Password  *parser.StringExpr `sensitive:"true"`
 }
 
 // Init inits resource instance
@@ -71,7 +72,7 @@ func (ad *FSAD) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (ad *FSAD) Create() (created bool, err error) {
+func (ad *FSAD) Create(ctx context.Context) (created bool, err error) {
 	if ad.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", ad.GetType())
 		return
@@ -81,7 +82,7 @@ func (ad *FSAD) Create() (created bool, err error) {
 	}
 
 	name := ad.getStringValue(ad.Name)
-	resourceID, err := ad.getResourceByName(name)
+	resourceID, err := ad.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -111,7 +112,7 @@ func (ad *FSAD) Create() (created bool, err error) {
 		userInfo.Password = ad.getStringValue(ad.Password)
 	}
 
-	body, err := ad.CallCreateAPI(req, nil)
+	body, err := ad.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs active directory %s", name)
 	}
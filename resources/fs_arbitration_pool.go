@@ -1,6 +1,8 @@
 package formation
 
 import (
+	"context"
+	"log"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -51,7 +53,7 @@ func (abPool *FSArbitrationPool) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (abPool *FSArbitrationPool) Create() (created bool, err error) {
+func (abPool *FSArbitrationPool) Create(ctx context.Context) (created bool, err error) {
 	if config.DryRun {
 		return abPool.fakeCreate()
 	}
@@ -61,7 +63,7 @@ func (abPool *FSArbitrationPool) Create() (created bool, err error) {
 		req.Info.PoolID = abPool.getIntegerValue(abPool.PoolID)
 	}
 
-	body, err := abPool.CallCreateAPI(req, nil)
+	body, err := abPool.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs arbitration pool")
 	}
@@ -74,3 +76,10 @@ func (abPool *FSArbitrationPool) Create() (created bool, err error) {
 	abPool.repr = id
 	return true, nil
 }
+
+// Delete delete the resource, deleting fs arbitration pool is not supported and will be skipped
+func (abPool *FSArbitrationPool) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	abPool.repr = repr
+	log.Printf("skip deleting fs arbitration pool %v", repr)
+	return true, nil
+}
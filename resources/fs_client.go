@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -56,7 +57,7 @@ func (client *FSClient) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (client *FSClient) Create() (created bool, err error) {
+func (client *FSClient) Create(ctx context.Context) (created bool, err error) {
 	if client.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", client.GetType())
 		return
@@ -66,7 +67,7 @@ func (client *FSClient) Create() (created bool, err error) {
 	}
 
 	name := client.getStringValue(client.Name)
-	resourceID, err := client.getResourceByName(name)
+	resourceID, err := client.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -84,7 +85,7 @@ func (client *FSClient) Create() (created bool, err error) {
 		clientInfo.IP = client.getStringValue(client.IP)
 	}
 
-	body, err := client.CallCreateAPI(req, nil)
+	body, err := client.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs client %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -54,7 +55,7 @@ func (group *FSClientGroup) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (group *FSClientGroup) Create() (created bool, err error) {
+func (group *FSClientGroup) Create(ctx context.Context) (created bool, err error) {
 	if group.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", group.GetType())
 		return
@@ -64,7 +65,7 @@ func (group *FSClientGroup) Create() (created bool, err error) {
 	}
 
 	name := group.getStringValue(group.Name)
-	resourceID, err := group.getResourceByName(name)
+	resourceID, err := group.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -82,7 +83,7 @@ func (group *FSClientGroup) Create() (created bool, err error) {
 		groupInfo.ClientIDs = group.getIntegerListValue(group.ClientIDs)
 	}
 
-	body, err := group.CallCreateAPI(req, nil)
+	body, err := group.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs client group %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -89,7 +90,7 @@ func (folder *FSFolder) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (folder *FSFolder) Create() (created bool, err error) {
+func (folder *FSFolder) Create(ctx context.Context) (created bool, err error) {
 	if folder.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", folder.GetType())
 		return
@@ -99,7 +100,7 @@ func (folder *FSFolder) Create() (created bool, err error) {
 	}
 
 	name := folder.getStringValue(folder.Name)
-	resourceID, err := folder.getResourceByName(name)
+	resourceID, err := folder.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -150,7 +151,7 @@ func (folder *FSFolder) Create() (created bool, err error) {
 		folderInfo.Qos = qosReq
 	}
 
-	body, err := folder.CallCreateAPI(req, nil)
+	body, err := folder.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs folder %s", name)
 	}
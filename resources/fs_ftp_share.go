@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -128,7 +129,7 @@ func (share *FSFTPShare) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (share *FSFTPShare) Create() (created bool, err error) {
+func (share *FSFTPShare) Create(ctx context.Context) (created bool, err error) {
 	if share.Name == nil {
 		return false, errors.Errorf("Name is required for resource %s", share.GetType())
 	}
@@ -137,7 +138,7 @@ func (share *FSFTPShare) Create() (created bool, err error) {
 	}
 
 	name := share.getStringValue(share.Name)
-	resourceID, err := share.getResourceByName(name)
+	resourceID, err := share.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -203,7 +204,7 @@ func (share *FSFTPShare) Create() (created bool, err error) {
 		ftpShare.ACLs = append(ftpShare.ACLs, aclReq)
 	}
 
-	body, err := share.CallCreateAPI(req, nil)
+	body, err := share.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs ftp share %s", name)
 	}
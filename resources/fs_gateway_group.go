@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -104,7 +105,7 @@ func (gatewayGroup *FSGatewayGroup) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (gatewayGroup *FSGatewayGroup) Create() (created bool, err error) {
+func (gatewayGroup *FSGatewayGroup) Create(ctx context.Context) (created bool, err error) {
 	if gatewayGroup.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", gatewayGroup.GetType())
 		return
@@ -114,7 +115,7 @@ func (gatewayGroup *FSGatewayGroup) Create() (created bool, err error) {
 	}
 
 	name := gatewayGroup.getStringValue(gatewayGroup.Name)
-	resourceID, err := gatewayGroup.getResourceByName(name)
+	resourceID, err := gatewayGroup.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -166,7 +167,7 @@ func (gatewayGroup *FSGatewayGroup) Create() (created bool, err error) {
 		groupInfo.Gateways = append(groupInfo.Gateways, gatewayReq)
 	}
 
-	body, err := gatewayGroup.CallCreateAPI(req, nil)
+	body, err := gatewayGroup.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs gateway group %s", name)
 	}
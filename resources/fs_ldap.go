@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -48,7 +49,7 @@ type FSLdap struct {
 	Port              *parser.IntegerExpr
 	Suffix            *parser.StringExpr
 	AdminDN           *parser.StringExpr
-	Password          *parser.StringExpr
+	Password          *parser.StringExpr `sensitive:"true"`
 	UserSuffix        *parser.StringExpr
 	GroupSuffix       *parser.StringExpr
 	Timeout           *parser.IntegerExpr
@@ -90,7 +91,7 @@ func (ldap *FSLdap) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (ldap *FSLdap) Create() (created bool, err error) {
+func (ldap *FSLdap) Create(ctx context.Context) (created bool, err error) {
 	if ldap.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", ldap.GetType())
 		return
@@ -100,7 +101,7 @@ func (ldap *FSLdap) Create() (created bool, err error) {
 	}
 
 	name := ldap.getStringValue(ldap.Name)
-	resourceID, err := ldap.getResourceByName(name)
+	resourceID, err := ldap.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -145,7 +146,7 @@ func (ldap *FSLdap) Create() (created bool, err error) {
 		userInfo.ConnectionTimeout = ldap.getIntegerValue(ldap.ConnectionTimeout)
 	}
 
-	body, err := ldap.CallCreateAPI(req, nil)
+	body, err := ldap.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs ldap %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -106,7 +107,7 @@ func (nfsShare *FSNFSShare) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (nfsShare *FSNFSShare) Create() (created bool, err error) {
+func (nfsShare *FSNFSShare) Create(ctx context.Context) (created bool, err error) {
 	if config.DryRun {
 		return nfsShare.fakeCreate()
 	}
@@ -160,7 +161,7 @@ func (nfsShare *FSNFSShare) Create() (created bool, err error) {
 		userInfo.ACLs = append(userInfo.ACLs, aclReq)
 	}
 
-	body, err := nfsShare.CallCreateAPI(req, nil)
+	body, err := nfsShare.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs nfs share")
 	}
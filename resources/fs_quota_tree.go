@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -66,7 +67,7 @@ func (quotaTree *FSFolderQuotaTree) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (quotaTree *FSFolderQuotaTree) Create() (created bool, err error) {
+func (quotaTree *FSFolderQuotaTree) Create(ctx context.Context) (created bool, err error) {
 	if quotaTree.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", quotaTree.GetType())
 		return
@@ -82,7 +83,7 @@ func (quotaTree *FSFolderQuotaTree) Create() (created bool, err error) {
 	name := quotaTree.getStringValue(quotaTree.Name)
 	folderID := quotaTree.getIntegerValue(quotaTree.FolderID)
 	params := map[string]string{"fs_folder_id": fmt.Sprintf("%d", folderID)}
-	resourceID, err := quotaTree.getResourceByName(name, params)
+	resourceID, err := quotaTree.getResourceByName(ctx, name, params)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -105,12 +106,15 @@ func (quotaTree *FSFolderQuotaTree) Create() (created bool, err error) {
 	}
 
 	pathParam := map[string]string{"fs_folder_id": fmt.Sprintf("%d", folderID)}
-	_, err = quotaTree.CallCreateAPI(req, pathParam)
+	_, err = quotaTree.CallCreateAPI(ctx, req, pathParam)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs quota tree %s", name)
 	}
+	if config.Plan {
+		return quotaTree.fakeCreate()
+	}
 
-	resourceID, err = quotaTree.getResourceByName(name, params)
+	resourceID, err = quotaTree.getResourceByName(ctx, name, params)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -115,7 +116,7 @@ func (share *FSSMBShare) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (share *FSSMBShare) Create() (created bool, err error) {
+func (share *FSSMBShare) Create(ctx context.Context) (created bool, err error) {
 	if share.Name == nil {
 		return false, errors.Errorf("Name is required for resource %s", share.GetType())
 	}
@@ -124,7 +125,7 @@ func (share *FSSMBShare) Create() (created bool, err error) {
 	}
 
 	name := share.getStringValue(share.Name)
-	resourceID, err := share.getResourceByName(name)
+	resourceID, err := share.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -184,7 +185,7 @@ func (share *FSSMBShare) Create() (created bool, err error) {
 		shareInfo.ACLs = append(shareInfo.ACLs, aclReq)
 	}
 
-	body, err := share.CallCreateAPI(req, nil)
+	body, err := share.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs smb share %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -35,7 +36,7 @@ type FSUser struct {
 	Type         *parser.StringExpr
 	Name         *parser.StringExpr
 	Email        *parser.StringExpr
-	Password     *parser.StringExpr
+	Password     *parser.StringExpr `sensitive:"true"`
 	FSADUserID   *parser.IntegerExpr
 	FSLdapUserID *parser.IntegerExpr
 }
@@ -71,7 +72,7 @@ func (user *FSUser) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (user *FSUser) Create() (created bool, err error) {
+func (user *FSUser) Create(ctx context.Context) (created bool, err error) {
 	if user.Name == nil {
 		return false, errors.Errorf("Name is required for resource %s", user.GetType())
 	}
@@ -80,7 +81,7 @@ func (user *FSUser) Create() (created bool, err error) {
 	}
 
 	name := user.getStringValue(user.Name)
-	resourceID, err := user.getResourceByName(name)
+	resourceID, err := user.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -110,7 +111,7 @@ func (user *FSUser) Create() (created bool, err error) {
 		userInfo.FSLdapUserID = user.getIntegerValue(user.FSLdapUserID)
 	}
 
-	body, err := user.CallCreateAPI(req, nil)
+	body, err := user.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs user %s", name)
 	}
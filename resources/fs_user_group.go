@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -67,7 +68,7 @@ func (userGroup *FSUserGroup) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (userGroup *FSUserGroup) Create() (created bool, err error) {
+func (userGroup *FSUserGroup) Create(ctx context.Context) (created bool, err error) {
 	if userGroup.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", userGroup.GetType())
 		return
@@ -77,7 +78,7 @@ func (userGroup *FSUserGroup) Create() (created bool, err error) {
 	}
 
 	name := userGroup.getStringValue(userGroup.Name)
-	resourceID, err := userGroup.getResourceByName(name)
+	resourceID, err := userGroup.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -104,7 +105,7 @@ func (userGroup *FSUserGroup) Create() (created bool, err error) {
 		userInfo.FSLdapUserGroupID = userGroup.getIntegerValue(userGroup.FSLdapUserGroupID)
 	}
 
-	body, err := userGroup.CallCreateAPI(req, nil)
+	body, err := userGroup.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create fs user group %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 	"time"
 
@@ -88,7 +89,7 @@ func (host *Host) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (host *Host) Create() (created bool, err error) {
+func (host *Host) Create(ctx context.Context) (created bool, err error) {
 	if config.DryRun {
 		return host.fakeCreate()
 	}
@@ -110,7 +111,7 @@ func (host *Host) Create() (created bool, err error) {
 		req.Host.Type = host.getStringValue(host.Type)
 	}
 
-	body, err := host.CallCreateAPI(req, nil)
+	body, err := host.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create host with admin ip %s", host.AdminIP)
 	}
@@ -121,13 +122,15 @@ func (host *Host) Create() (created bool, err error) {
 }
 
 // IsCreated if the resource has been created
-func (host *Host) IsCreated() (created bool, err error) {
-	created, err = host.ResourceBase.IsCreated()
+func (host *Host) IsCreated(ctx context.Context) (created bool, err error) {
+	created, err = host.ResourceBase.IsCreated(ctx)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
 	if created {
-		time.Sleep(5 * time.Second)
+		if err = utils.Sleep(ctx, 5*time.Second); err != nil {
+			return false, errors.Trace(err)
+		}
 	}
 	return created, nil
 }
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -64,7 +65,7 @@ func (hosts *Hosts) fakeCreate() (bool, error) {
 }
 
 // Get get resource from server
-func (hosts *Hosts) Get() error {
+func (hosts *Hosts) Get(ctx context.Context) error {
 	if config.DryRun {
 		hosts.repr = []int64{rand.Int63(), rand.Int63()}
 		return nil
@@ -72,7 +73,7 @@ func (hosts *Hosts) Get() error {
 
 	// no limit when list resource
 	queryParam := map[string]string{"limit": "-1"}
-	body, err := hosts.CallResourceAPI(utils.ListAPIName, nil, nil, queryParam)
+	body, err := hosts.CallResourceAPI(ctx, utils.ListAPIName, nil, nil, queryParam)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -127,7 +128,7 @@ func (hosts *Hosts) Get() error {
 }
 
 // Create create the resource
-func (hosts *Hosts) Create() (created bool, err error) {
+func (hosts *Hosts) Create(ctx context.Context) (created bool, err error) {
 	if hosts.AdminIPs == nil {
 		err = errors.Errorf("AdminIPs is required")
 		return
@@ -155,7 +156,7 @@ func (hosts *Hosts) Create() (created bool, err error) {
 	adminIPs := hosts.getStringListValue(hosts.AdminIPs)
 	for _, adminIP := range adminIPs {
 		req.Host.AdminIP = adminIP
-		body, err := hosts.CallCreateAPI(req, nil)
+		body, err := hosts.CallCreateAPI(ctx, req, nil)
 		if err != nil {
 			return false, errors.Annotatef(err, "create host with admin ip %s", adminIP)
 		}
@@ -172,7 +173,7 @@ func (hosts *Hosts) Create() (created bool, err error) {
 }
 
 // IsCreated if the resource has been created
-func (hosts *Hosts) IsCreated() (created bool, err error) {
+func (hosts *Hosts) IsCreated(ctx context.Context) (created bool, err error) {
 	creatingHostIDs := []int64{}
 	getReqIdentifyKey, err := settings.GetSetting(hosts.GetType(), utils.GetReqIdentify)
 	if err != nil {
@@ -180,7 +181,7 @@ func (hosts *Hosts) IsCreated() (created bool, err error) {
 	}
 	for _, hostID := range hosts.creatingHostIDs {
 		pathParam := map[string]string{getReqIdentifyKey: fmt.Sprintf("%d", hostID)}
-		body, err := hosts.CallGetAPI(pathParam)
+		body, err := hosts.CallGetAPI(ctx, pathParam)
 		if err != nil {
 			return false, errors.Annotatef(err, "get host with id %d", hostID)
 		}
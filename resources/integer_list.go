@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"xsky.com/sds-formation/parser"
 	"xsky.com/sds-formation/utils"
 )
@@ -34,7 +35,7 @@ func (integerList *IntegerList) IsReady() (ready bool) {
 }
 
 // Create create the resource
-func (integerList *IntegerList) Create() (created bool, err error) {
+func (integerList *IntegerList) Create(ctx context.Context) (created bool, err error) {
 	repr := []int64{}
 	for _, attr := range integerList.Attributes {
 		val := integerList.getIntegerListValue(attr)
@@ -45,3 +46,9 @@ func (integerList *IntegerList) Create() (created bool, err error) {
 
 	return
 }
+
+// Delete delete the resource, nothing to delete since integer list is calculated locally
+func (integerList *IntegerList) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	integerList.repr = repr
+	return true, nil
+}
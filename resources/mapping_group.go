@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"math/rand"
 
@@ -58,10 +59,10 @@ func (mappingGroup *MappingGroup) fakeCreate() (bool, error) {
 	return true, nil
 }
 
-func (mappingGroup *MappingGroup) getResource(accessPathID, clientGroupID int64) (
+func (mappingGroup *MappingGroup) getResource(ctx context.Context, accessPathID, clientGroupID int64) (
 	resourceID int64, err error) {
 
-	body, err := mappingGroup.CallResourceAPI(utils.ListAPIName, nil, nil)
+	body, err := mappingGroup.CallResourceAPI(ctx, utils.ListAPIName, nil, nil)
 	if err != nil {
 		return 0, errors.Annotatef(err, "list mapping groups")
 	}
@@ -91,7 +92,7 @@ func (mappingGroup *MappingGroup) getResource(accessPathID, clientGroupID int64)
 }
 
 // Create create the resource
-func (mappingGroup *MappingGroup) Create() (created bool, err error) {
+func (mappingGroup *MappingGroup) Create(ctx context.Context) (created bool, err error) {
 	if mappingGroup.AccessPathID == nil || mappingGroup.ClientGroupID == nil {
 		return false, errors.Errorf("AccessPathID and ClientGroupID is required for resource %s",
 			mappingGroup.GetType())
@@ -102,7 +103,7 @@ func (mappingGroup *MappingGroup) Create() (created bool, err error) {
 
 	accessPathID := mappingGroup.getIntegerValue(mappingGroup.AccessPathID)
 	clientGroupID := mappingGroup.getIntegerValue(mappingGroup.ClientGroupID)
-	resourceID, err := mappingGroup.getResource(accessPathID, clientGroupID)
+	resourceID, err := mappingGroup.getResource(ctx, accessPathID, clientGroupID)
 	if err != nil {
 		return false, errors.Annotatef(err,
 			"get mapping group with access path id %d and client group id %d",
@@ -110,6 +111,7 @@ func (mappingGroup *MappingGroup) Create() (created bool, err error) {
 	}
 	if resourceID > 0 {
 		mappingGroup.repr = resourceID
+		mappingGroup.adopted = true
 		return false, nil
 	}
 
@@ -120,7 +122,7 @@ func (mappingGroup *MappingGroup) Create() (created bool, err error) {
 		req.MappingGroup.BlockVolumeIds = mappingGroup.getIntegerListValue(mappingGroup.BlockVolumeIDs)
 	}
 
-	body, err := mappingGroup.CallCreateAPI(req, nil)
+	body, err := mappingGroup.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err,
 			"create mapping group with access path id %d and client group id %d",
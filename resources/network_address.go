@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"math/rand"
 
@@ -39,7 +40,7 @@ func (address *NetworkAddress) IsReady() (ready bool) {
 }
 
 // Get get resource from server
-func (address *NetworkAddress) Get() error {
+func (address *NetworkAddress) Get(ctx context.Context) error {
 	if address.IP == nil {
 		return errors.Errorf("IP is needed for get netword address")
 	}
@@ -50,7 +51,7 @@ func (address *NetworkAddress) Get() error {
 
 	// no limit when list resource
 	queryParam := map[string]string{"limit": "-1"}
-	body, err := address.CallResourceAPI(utils.ListAPIName, nil, nil, queryParam)
+	body, err := address.CallResourceAPI(ctx, utils.ListAPIName, nil, nil, queryParam)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -90,3 +91,9 @@ func (address *NetworkAddress) Get() error {
 
 	return nil
 }
+
+// Delete delete the resource, nothing to delete since network address only queries existing addresses
+func (address *NetworkAddress) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	address.repr = repr
+	return true, nil
+}
@@ -1,6 +1,8 @@
 package formation
 
 import (
+	"context"
+	"log"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -60,8 +62,8 @@ func (os *ObjectStorage) fakeCreate() (bool, error) {
 	return true, nil
 }
 
-func (os *ObjectStorage) getResource() (resourceID int64, err error) {
-	body, err := os.CallGetAPI()
+func (os *ObjectStorage) getResource(ctx context.Context) (resourceID int64, err error) {
+	body, err := os.CallGetAPI(ctx)
 	if err != nil {
 		return 0, errors.Annotatef(err, "get object storage")
 	}
@@ -78,18 +80,19 @@ func (os *ObjectStorage) getResource() (resourceID int64, err error) {
 }
 
 // Create create the resource
-func (os *ObjectStorage) Create() (created bool, err error) {
+func (os *ObjectStorage) Create(ctx context.Context) (created bool, err error) {
 	if config.DryRun {
 		return os.fakeCreate()
 	}
 
-	resourceID, err := os.getResource()
+	resourceID, err := os.getResource(ctx)
 	if err != nil {
 		err = errors.Annotatef(err, "failed to get object storage")
 		return
 	}
 	if resourceID > 0 {
 		os.repr = resourceID
+		os.adopted = true
 		return false, nil
 	}
 
@@ -101,7 +104,7 @@ func (os *ObjectStorage) Create() (created bool, err error) {
 	if os.ArchivePoolID != nil {
 		osInfo.ArchivePoolID = os.getIntegerValue(os.ArchivePoolID)
 	}
-	body, err := os.CallCreateAPI(req, nil)
+	body, err := os.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "init object storage")
 	}
@@ -114,3 +117,10 @@ func (os *ObjectStorage) Create() (created bool, err error) {
 
 	return false, nil
 }
+
+// Delete delete the resource, deleting object storage is not supported and will be skipped
+func (os *ObjectStorage) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	os.repr = repr
+	log.Printf("skip deleting object storage %v", repr)
+	return true, nil
+}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"math/rand"
 
@@ -50,8 +51,10 @@ func (pool *ObjectStorageArchivePool) fakeCreate() (bool, error) {
 	return true, nil
 }
 
-func (pool *ObjectStorageArchivePool) getResource(poolID int64) (resourceID int64, err error) {
-	body, err := pool.CallResourceAPI(utils.ListAPIName, nil, nil)
+func (pool *ObjectStorageArchivePool) getResource(ctx context.Context, poolID int64) (
+	resourceID int64, err error) {
+
+	body, err := pool.CallResourceAPI(ctx, utils.ListAPIName, nil, nil)
 	if err != nil {
 		return 0, errors.Annotatef(err, "list archive pools")
 	}
@@ -76,7 +79,7 @@ func (pool *ObjectStorageArchivePool) getResource(poolID int64) (resourceID int6
 }
 
 // Create create the resource
-func (pool *ObjectStorageArchivePool) Create() (created bool, err error) {
+func (pool *ObjectStorageArchivePool) Create(ctx context.Context) (created bool, err error) {
 	if pool.PoolID == nil {
 		return false, errors.Errorf("PoolID is required for resource %s", pool.GetType())
 	}
@@ -85,18 +88,19 @@ func (pool *ObjectStorageArchivePool) Create() (created bool, err error) {
 	}
 
 	poolID := pool.getIntegerValue(pool.PoolID)
-	resourceID, err := pool.getResource(poolID)
+	resourceID, err := pool.getResource(ctx, poolID)
 	if err != nil {
 		return false, errors.Annotatef(err, "failed to get pool with id %d", poolID)
 	}
 	if resourceID > 0 {
 		pool.repr = resourceID
+		pool.adopted = true
 		return true, nil
 	}
 
 	req := new(OSArchivePoolCreateReq)
 	req.ArchivePool.PoolID = pool.getIntegerValue(pool.PoolID)
-	body, err := pool.CallCreateAPI(req, nil)
+	body, err := pool.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err,
 			"create object storage archive pool using pool with id %d", poolID)
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -99,7 +100,7 @@ func (bucket *ObjectStorageBucket) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (bucket *ObjectStorageBucket) Create() (created bool, err error) {
+func (bucket *ObjectStorageBucket) Create(ctx context.Context) (created bool, err error) {
 	if bucket.Name == nil {
 		return false, errors.Errorf("Name is required for resource %s", bucket.GetType())
 	}
@@ -108,7 +109,7 @@ func (bucket *ObjectStorageBucket) Create() (created bool, err error) {
 	}
 
 	name := bucket.getStringValue(bucket.Name)
-	resourceID, err := bucket.getResourceByName(name)
+	resourceID, err := bucket.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -144,7 +145,7 @@ func (bucket *ObjectStorageBucket) Create() (created bool, err error) {
 		bucketInfo.QuotaMaxSize = bucket.getIntegerValue(bucket.QuotaMaxSize)
 	}
 
-	body, err := bucket.CallCreateAPI(req, nil)
+	body, err := bucket.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create bucket %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -66,7 +67,7 @@ func (gateway *ObjectStorageGateway) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (gateway *ObjectStorageGateway) Create() (created bool, err error) {
+func (gateway *ObjectStorageGateway) Create(ctx context.Context) (created bool, err error) {
 	if gateway.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", gateway.GetType())
 		return
@@ -76,7 +77,7 @@ func (gateway *ObjectStorageGateway) Create() (created bool, err error) {
 	}
 
 	name := gateway.getStringValue(gateway.Name)
-	resourceID, err := gateway.getResourceByName(name)
+	resourceID, err := gateway.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -103,7 +104,7 @@ func (gateway *ObjectStorageGateway) Create() (created bool, err error) {
 		gatewayInfo.Port = gateway.getIntegerValue(gateway.Port)
 	}
 
-	body, err := gateway.CallCreateAPI(req, nil)
+	body, err := gateway.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "failed to create object storage gateway %s", name)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -157,7 +158,7 @@ func (policy *ObjectStoragePolicy) parseReqFromOldFormatTemplate() *OSPolicyCrea
 }
 
 // Create create the resource
-func (policy *ObjectStoragePolicy) Create() (created bool, err error) {
+func (policy *ObjectStoragePolicy) Create(ctx context.Context) (created bool, err error) {
 	if policy.Name == nil {
 		return false, fmt.Errorf("Name is required for resource %s", policy.GetType())
 	}
@@ -166,7 +167,7 @@ func (policy *ObjectStoragePolicy) Create() (created bool, err error) {
 	}
 
 	name := policy.getStringValue(policy.Name)
-	resourceID, err := policy.getResourceByName(name)
+	resourceID, err := policy.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -230,7 +231,7 @@ func (policy *ObjectStoragePolicy) Create() (created bool, err error) {
 		req.Policy.Shared = &shared
 	}
 
-	body, err := policy.CallCreateAPI(req, nil)
+	body, err := policy.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create object storage policy %s", name)
 	}
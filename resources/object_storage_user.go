@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 	"time"
 
@@ -57,7 +58,7 @@ type OSUserCreateReq struct {
 // ObjectStorageKey resource
 type ObjectStorageKey struct {
 	AccessKey *parser.StringExpr
-	SecretKey *parser.StringExpr
+	SecretKey *parser.StringExpr `sensitive:"true"`
 }
 
 // ObjectStorageUser resource
@@ -116,7 +117,7 @@ func (user *ObjectStorageUser) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (user *ObjectStorageUser) Create() (created bool, err error) {
+func (user *ObjectStorageUser) Create(ctx context.Context) (created bool, err error) {
 	if user.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", user.GetType())
 		return
@@ -126,7 +127,7 @@ func (user *ObjectStorageUser) Create() (created bool, err error) {
 	}
 
 	name := user.getStringValue(user.Name)
-	resourceID, err := user.getResourceByName(name)
+	resourceID, err := user.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -174,7 +175,7 @@ func (user *ObjectStorageUser) Create() (created bool, err error) {
 		userInfo.Keys = append(userInfo.Keys, *key)
 	}
 
-	body, err := user.CallCreateAPI(req, nil)
+	body, err := user.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create object storage user %s", name)
 	}
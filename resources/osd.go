@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"math/rand"
 
@@ -71,8 +72,8 @@ func (osd *Osd) fakeCreate() (bool, error) {
 	return true, nil
 }
 
-func (osd *Osd) getResource(diskID int64) (resourceID int64, err error) {
-	body, err := osd.CallResourceAPI(utils.ListAPIName, nil, nil)
+func (osd *Osd) getResource(ctx context.Context, diskID int64) (resourceID int64, err error) {
+	body, err := osd.CallResourceAPI(ctx, utils.ListAPIName, nil, nil)
 	if err != nil {
 		return 0, errors.Annotatef(err, "list osds")
 	}
@@ -98,7 +99,7 @@ func (osd *Osd) getResource(diskID int64) (resourceID int64, err error) {
 }
 
 // Create create the resource
-func (osd *Osd) Create() (created bool, err error) {
+func (osd *Osd) Create(ctx context.Context) (created bool, err error) {
 	if osd.DiskID == nil {
 		return false, errors.Errorf("DiskID is required for resource %s", osd.GetType())
 	}
@@ -107,12 +108,13 @@ func (osd *Osd) Create() (created bool, err error) {
 	}
 
 	diskID := osd.getIntegerValue(osd.DiskID)
-	resourceID, err := osd.getResource(diskID)
+	resourceID, err := osd.getResource(ctx, diskID)
 	if err != nil {
 		return false, errors.Annotatef(err, "get osd with disk %d", diskID)
 	}
 	if resourceID > 0 {
 		osd.repr = resourceID
+		osd.adopted = true
 		return false, nil
 	}
 
@@ -131,7 +133,7 @@ func (osd *Osd) Create() (created bool, err error) {
 		osdInfo.OmapByte = osd.getIntegerValue(osd.OmapByte)
 	}
 
-	body, err := osd.CallCreateAPI(req, nil)
+	body, err := osd.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create osd with disk %d", diskID)
 	}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -58,8 +59,8 @@ func (osds *Osds) fakeCreate() (bool, error) {
 	return true, nil
 }
 
-func (osds *Osds) getResource(diskIDs []int64) (diskMap map[int64]int64, err error) {
-	body, err := osds.CallResourceAPI(utils.ListAPIName, nil, nil)
+func (osds *Osds) getResource(ctx context.Context, diskIDs []int64) (diskMap map[int64]int64, err error) {
+	body, err := osds.CallResourceAPI(ctx, utils.ListAPIName, nil, nil)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to list osds")
 	}
@@ -88,7 +89,7 @@ func (osds *Osds) getResource(diskIDs []int64) (diskMap map[int64]int64, err err
 }
 
 // Create create the resource
-func (osds *Osds) Create() (created bool, err error) {
+func (osds *Osds) Create(ctx context.Context) (created bool, err error) {
 	if osds.DiskIDs == nil {
 		return false, errors.Errorf("HostIDs is required")
 	}
@@ -102,7 +103,7 @@ func (osds *Osds) Create() (created bool, err error) {
 		log.Printf("Skip creating osds with zero disks")
 		return true, nil
 	}
-	diskMap, err := osds.getResource(diskIDs)
+	diskMap, err := osds.getResource(ctx, diskIDs)
 	if err != nil {
 		return false, errors.Annotatef(err, "get osds with disks %+v", diskIDs)
 	}
@@ -117,10 +118,12 @@ func (osds *Osds) Create() (created bool, err error) {
 	log.Printf("try to create %d %s osds using %d cache disks", len(diskIDs), role, len(partitionIDs))
 
 	osdIDs := []int64{}
+	adoptedItems := []string{}
 	for index, diskID := range diskIDs {
 		osdID, ok := diskMap[diskID]
 		if ok {
 			osdIDs = append(osdIDs, osdID)
+			adoptedItems = append(adoptedItems, fmt.Sprintf("%d", osdID))
 			continue
 		}
 
@@ -134,7 +137,7 @@ func (osds *Osds) Create() (created bool, err error) {
 			req.Osd.OmapByte = osds.getIntegerValue(osds.OmapByte)
 		}
 
-		body, err := osds.CallCreateAPI(req, nil)
+		body, err := osds.CallCreateAPI(ctx, req, nil)
 		if err != nil {
 			return false, errors.Annotatef(err, "create osd with disk %d", diskID)
 		}
@@ -146,13 +149,19 @@ func (osds *Osds) Create() (created bool, err error) {
 		osdIDs = append(osdIDs, id.(int64))
 	}
 
+	// existing osds shouldn't be deleted with created ones
+	if len(adoptedItems) == len(osdIDs) {
+		osds.adopted = len(osdIDs) != 0
+	} else {
+		osds.adoptedItems = adoptedItems
+	}
 	osds.repr = osdIDs
 	osds.creatingOsdIDs = osdIDs
 	return false, nil
 }
 
 // IsCreated if the resource has been created
-func (osds *Osds) IsCreated() (created bool, err error) {
+func (osds *Osds) IsCreated(ctx context.Context) (created bool, err error) {
 	creatingOsdIDs := []int64{}
 	getReqIdentify, err := settings.GetSetting(osds.GetType(), utils.GetReqIdentify)
 	if err != nil {
@@ -160,7 +169,7 @@ func (osds *Osds) IsCreated() (created bool, err error) {
 	}
 	for _, osdID := range osds.creatingOsdIDs {
 		pathParam := map[string]string{getReqIdentify: fmt.Sprintf("%d", osdID)}
-		body, err := osds.CallGetAPI(pathParam)
+		body, err := osds.CallGetAPI(ctx, pathParam)
 		if err != nil {
 			return false, errors.Annotatef(err, "get osd with id %d", osdID)
 		}
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -59,7 +60,7 @@ func (partitions *Partitions) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (partitions *Partitions) Create() (created bool, err error) {
+func (partitions *Partitions) Create(ctx context.Context) (created bool, err error) {
 	if partitions.DiskIDs == nil {
 		return false, errors.Errorf("HostIDs is required")
 	}
@@ -81,11 +82,18 @@ func (partitions *Partitions) Create() (created bool, err error) {
 	for _, diskID := range diskIDs {
 		pathParam := map[string]string{getReqIdentifyKey: fmt.Sprintf("%d", diskID)}
 		queryParam := map[string]string{"num": fmt.Sprintf("%d", numPerDisk)}
-		_, err = partitions.CallCreateAPI(nil, pathParam, queryParam)
+		_, err = partitions.CallCreateAPI(ctx, nil, pathParam, queryParam)
 		if err != nil {
 			return false, errors.Annotatef(err, "create partition with disk %d", diskID)
 		}
 	}
+	if config.Plan {
+		for i := 0; i < int(numPerDisk)*len(diskIDs); i++ {
+			partitions.partitionIDs = append(partitions.partitionIDs, rand.Int63())
+		}
+		partitions.repr = partitions.partitionIDs
+		return true, nil
+	}
 
 	partitions.cachingDiskIDs = diskIDs
 	partitions.partitionIDs = make([]int64, 0, int(numPerDisk)*len(diskIDs))
@@ -93,7 +101,7 @@ func (partitions *Partitions) Create() (created bool, err error) {
 }
 
 // IsCreated if the resource has been created
-func (partitions *Partitions) IsCreated() (created bool, err error) {
+func (partitions *Partitions) IsCreated(ctx context.Context) (created bool, err error) {
 	cachingDiskIDs := []int64{}
 	getReqIdentifyKey, err := settings.GetSetting(partitions.GetType(), utils.GetReqIdentify)
 	if err != nil {
@@ -101,7 +109,7 @@ func (partitions *Partitions) IsCreated() (created bool, err error) {
 	}
 	for _, diskID := range partitions.cachingDiskIDs {
 		pathParam := map[string]string{getReqIdentifyKey: fmt.Sprintf("%d", diskID)}
-		body, err := partitions.CallGetAPI(pathParam)
+		body, err := partitions.CallGetAPI(ctx, pathParam)
 		if err != nil {
 			return false, errors.Trace(err)
 		}
@@ -142,3 +150,10 @@ func (partitions *Partitions) IsCreated() (created bool, err error) {
 	partitions.cachingDiskIDs = cachingDiskIDs
 	return false, nil
 }
+
+// Delete delete the resource, deleting partitions is not supported and will be skipped
+func (partitions *Partitions) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	partitions.repr = repr
+	log.Printf("skip deleting partitions %v", repr)
+	return true, nil
+}
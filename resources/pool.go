@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -99,7 +100,7 @@ func (pool *Pool) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (pool *Pool) Create() (created bool, err error) {
+func (pool *Pool) Create(ctx context.Context) (created bool, err error) {
 	if pool.Name == nil {
 		err = errors.Errorf("Name is required for resource %s", pool.GetType())
 		return
@@ -109,7 +110,7 @@ func (pool *Pool) Create() (created bool, err error) {
 	}
 
 	name := pool.getStringValue(pool.Name)
-	resourceID, err := pool.getResourceByName(name)
+	resourceID, err := pool.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Annotatef(err, "get pool %s", name)
 	}
@@ -151,7 +152,7 @@ func (pool *Pool) Create() (created bool, err error) {
 		poolInfo.Size = pool.getIntegerValue(pool.Size)
 	}
 
-	body, err := pool.CallCreateAPI(req, nil)
+	body, err := pool.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create pool %s", name)
 	}
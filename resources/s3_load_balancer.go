@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/juju/errors"
@@ -92,7 +93,7 @@ func (lbg *S3LoadBalancerGroup) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (lbg *S3LoadBalancerGroup) Create() (created bool, err error) {
+func (lbg *S3LoadBalancerGroup) Create(ctx context.Context) (created bool, err error) {
 	if lbg.Name == nil {
 		return false, errors.Errorf("Name is required for resource %s", lbg.GetType())
 	}
@@ -101,7 +102,7 @@ func (lbg *S3LoadBalancerGroup) Create() (created bool, err error) {
 	}
 
 	name := lbg.getStringValue(lbg.Name)
-	resourceID, err := lbg.getResourceByName(name)
+	resourceID, err := lbg.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
@@ -122,7 +123,7 @@ func (lbg *S3LoadBalancerGroup) Create() (created bool, err error) {
 		lbGroupInfo.S3LoadBalancers = append(lbGroupInfo.S3LoadBalancers, balancerReq)
 	}
 
-	body, err := lbg.CallCreateAPI(req, nil)
+	body, err := lbg.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create s3 load balancer group %s", name)
 	}
@@ -47,6 +47,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "access_path",
 		utils.RecordsKey:     "access_paths",
 		utils.CreateAPIName:  "CreateAccessPath",
+		utils.DeleteAPIName:  "DeleteAccessPath",
 	},
 	utils.ResourceBlockVolume: {
 		utils.GetReqIdentify: "block_volume_id",
@@ -55,6 +56,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "block_volumes",
 		utils.RecordKey:      "block_volume",
 		utils.CreateAPIName:  "CreateBlockVolume",
+		utils.DeleteAPIName:  "DeleteBlockVolume",
 	},
 	utils.ResourceBlockVolumes: {
 		utils.ListAPIName:    "ListBlockVolumes",
@@ -63,6 +65,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "block_volume",
 		utils.RecordsKey:     "block_volumes",
 		utils.CreateAPIName:  "CreateBlockVolume",
+		utils.DeleteAPIName:  "DeleteBlockVolume",
 	},
 	utils.ResourceBootNode: {
 		utils.GetAPIName:    "BootNode",
@@ -76,6 +79,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "client_group",
 		utils.RecordsKey:     "client_groups",
 		utils.CreateAPIName:  "CreateClientGroup",
+		utils.DeleteAPIName:  "DeleteClientGroup",
 	},
 	utils.ResourceDiskList: {
 		utils.ListAPIName:    "ListDisks",
@@ -87,6 +91,7 @@ var settings = resourceSettings{
 		utils.GetAPIName:     "GetHost",
 		utils.GetReqIdentify: "host_id",
 		utils.CreateAPIName:  "CreateHost",
+		utils.DeleteAPIName:  "DeleteHost",
 	},
 	utils.ResourceHosts: {
 		utils.RecordsKey:     "hosts",
@@ -95,6 +100,7 @@ var settings = resourceSettings{
 		utils.CreateAPIName:  "CreateHost",
 		utils.GetAPIName:     "GetHost",
 		utils.GetReqIdentify: "host_id",
+		utils.DeleteAPIName:  "DeleteHost",
 	},
 	utils.ResourceMappingGroup: {
 		utils.RecordKey:      "mapping_group",
@@ -102,6 +108,7 @@ var settings = resourceSettings{
 		utils.GetReqIdentify: "mapping_group_id",
 		utils.ListAPIName:    "ListMappingGroups",
 		utils.CreateAPIName:  "CreateMappingGroup",
+		utils.DeleteAPIName:  "DeleteMappingGroup",
 	},
 	utils.ResourceNFSGateway: {
 		utils.RecordKey:      "nfs_gateway",
@@ -110,6 +117,7 @@ var settings = resourceSettings{
 		utils.GetReqIdentify: "gateway_id",
 		utils.ListAPIName:    "ListNFSGateways",
 		utils.CreateAPIName:  "CreateNFSGateway",
+		utils.DeleteAPIName:  "DeleteNFSGateway",
 	},
 	utils.ResourceObjectStorage: {
 		utils.RecordKey:     "object_storage",
@@ -123,6 +131,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "os_archive_pool",
 		utils.RecordsKey:     "os_archive_pools",
 		utils.CreateAPIName:  "CreateArchivePool",
+		utils.DeleteAPIName:  "DeleteArchivePool",
 	},
 	utils.ResourceObjectStorageBucket: {
 		utils.ListAPIName:    "ListBuckets",
@@ -131,6 +140,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "os_bucket",
 		utils.RecordsKey:     "os_buckets",
 		utils.CreateAPIName:  "CreateBucket",
+		utils.DeleteAPIName:  "DeleteBucket",
 	},
 	utils.ResourceObjectStorageGateway: {
 		utils.ListAPIName:    "ListGateways",
@@ -139,6 +149,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "os_gateway",
 		utils.RecordsKey:     "os_gateways",
 		utils.CreateAPIName:  "CreateGateway",
+		utils.DeleteAPIName:  "DeleteGateway",
 	},
 	utils.ResourceObjectStoragePolicy: {
 		utils.GetReqIdentify: "policy_id",
@@ -147,6 +158,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "os_policy",
 		utils.RecordsKey:     "os_policies",
 		utils.CreateAPIName:  "CreatePolicy",
+		utils.DeleteAPIName:  "DeletePolicy",
 	},
 	utils.ResourceObjectStorageUser: {
 		utils.GetReqIdentify: "user_id",
@@ -155,6 +167,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "os_user",
 		utils.RecordsKey:     "os_users",
 		utils.CreateAPIName:  "CreateObjectStorageUser",
+		utils.DeleteAPIName:  "DeleteObjectStorageUser",
 	},
 	utils.ResourceOsd: {
 		utils.GetAPIName:     "GetOsd",
@@ -162,6 +175,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "osd",
 		utils.ListAPIName:    "ListOsds",
 		utils.CreateAPIName:  "CreateOsd",
+		utils.DeleteAPIName:  "DeleteOsd",
 	},
 	utils.ResourceOsds: {
 		utils.GetAPIName:     "GetOsd",
@@ -169,6 +183,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "osd",
 		utils.ListAPIName:    "ListOsds",
 		utils.CreateAPIName:  "CreateOsd",
+		utils.DeleteAPIName:  "DeleteOsd",
 	},
 	utils.ResourcePartitions: {
 		utils.CreateAPIName:  "CreatePartitions",
@@ -182,6 +197,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "pool",
 		utils.RecordsKey:     "pools",
 		utils.CreateAPIName:  "CreatePool",
+		utils.DeleteAPIName:  "DeletePool",
 	},
 	utils.ResourceS3LoadBalancerGroup: {
 		utils.ListAPIName:    "ListS3LoadBalancerGroups",
@@ -190,6 +206,7 @@ var settings = resourceSettings{
 		utils.RecordKey:      "s3_load_balancer_group",
 		utils.RecordsKey:     "s3_load_balancer_groups",
 		utils.CreateAPIName:  "CreateS3LoadBalancerGroup",
+		utils.DeleteAPIName:  "DeleteS3LoadBalancerGroup",
 	},
 	utils.ResourceToken: {
 		utils.CreateAPIName: "CreateToken",
@@ -201,6 +218,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "users",
 		utils.ListAPIName:    "ListUsers",
 		utils.CreateAPIName:  "CreateUser",
+		utils.DeleteAPIName:  "DeleteUser",
 	},
 	utils.ResourceFSUser: {
 		utils.GetAPIName:     "GetFSUser",
@@ -209,6 +227,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_users",
 		utils.ListAPIName:    "ListFSUsers",
 		utils.CreateAPIName:  "CreateFSUser",
+		utils.DeleteAPIName:  "DeleteFSUser",
 	},
 	utils.ResourceFSUserGroup: {
 		utils.GetAPIName:     "GetFSUserGroup",
@@ -217,6 +236,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_user_groups",
 		utils.ListAPIName:    "ListFSUserGroups",
 		utils.CreateAPIName:  "CreateFSUserGroup",
+		utils.DeleteAPIName:  "DeleteFSUserGroup",
 	},
 	utils.ResourceFSFolder: {
 		utils.GetAPIName:     "GetFolder",
@@ -225,6 +245,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_folders",
 		utils.ListAPIName:    "ListFolders",
 		utils.CreateAPIName:  "CreateFolder",
+		utils.DeleteAPIName:  "DeleteFolder",
 	},
 	utils.ResourceFSClient: {
 		utils.GetAPIName:     "GetFSClient",
@@ -233,6 +254,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_clients",
 		utils.ListAPIName:    "ListFSClients",
 		utils.CreateAPIName:  "CreateFSClient",
+		utils.DeleteAPIName:  "DeleteFSClient",
 	},
 	utils.ResourceFSClientGroup: {
 		utils.GetAPIName:     "GetFSClientGroup",
@@ -241,6 +263,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_client_groups",
 		utils.ListAPIName:    "ListFSClientGroups",
 		utils.CreateAPIName:  "CreateFSClientGroup",
+		utils.DeleteAPIName:  "DeleteFSClientGroup",
 	},
 	utils.ResourceFSGatewayGroup: {
 		utils.GetAPIName:     "GetFSGatewayGroup",
@@ -249,6 +272,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_gateway_groups",
 		utils.ListAPIName:    "ListFSGatewayGroups",
 		utils.CreateAPIName:  "CreateFSGatewayGroup",
+		utils.DeleteAPIName:  "DeleteFSGatewayGroup",
 	},
 	utils.ResourceFSLdap: {
 		utils.GetAPIName:     "GetFSLdap",
@@ -258,6 +282,7 @@ var settings = resourceSettings{
 		utils.ListAPIName:    "ListFSLdaps",
 		utils.CreateAPIName:  "CreateFSLdap",
 		utils.StatusKey:      "ActionStatus",
+		utils.DeleteAPIName:  "DeleteFSLdap",
 	},
 	utils.ResourceFSAD: {
 		utils.GetAPIName:     "GetFSActiveDirectory",
@@ -267,6 +292,7 @@ var settings = resourceSettings{
 		utils.ListAPIName:    "ListFSActiveDirectories",
 		utils.CreateAPIName:  "CreateFSActiveDirectory",
 		utils.StatusKey:      "ActionStatus",
+		utils.DeleteAPIName:  "DeleteFSActiveDirectory",
 	},
 	utils.ResourceFSNFSShare: {
 		utils.GetAPIName:     "GetFSNFSShare",
@@ -275,6 +301,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_nfs_shares",
 		utils.ListAPIName:    "ListFSNFSShares",
 		utils.CreateAPIName:  "CreateFSNFSShare",
+		utils.DeleteAPIName:  "DeleteFSNFSShare",
 	},
 	utils.ResourceFSFTPShare: {
 		utils.GetAPIName:     "GetFSFTPShare",
@@ -283,6 +310,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_ftp_shares",
 		utils.ListAPIName:    "ListFSFTPShares",
 		utils.CreateAPIName:  "CreateFSFTPShare",
+		utils.DeleteAPIName:  "DeleteFSFTPShare",
 	},
 	utils.ResourceFSSMBShare: {
 		utils.GetAPIName:     "GetFSSMBShare",
@@ -291,6 +319,7 @@ var settings = resourceSettings{
 		utils.RecordsKey:     "fs_smb_shares",
 		utils.ListAPIName:    "ListFSSMBShares",
 		utils.CreateAPIName:  "CreateFSSMBShare",
+		utils.DeleteAPIName:  "DeleteFSSMBShare",
 	},
 	utils.ResourceNetworkAddress: {
 		utils.RecordsKey:  "network_addresses",
@@ -303,6 +332,7 @@ var settings = resourceSettings{
 		utils.GetReqIdentify: "fs_quota_tree_id",
 		utils.ListAPIName:    "ListQuotaTrees",
 		utils.CreateAPIName:  "AddFSQuotaTrees",
+		utils.DeleteAPIName:  "DeleteQuotaTree",
 	},
 	utils.ResourceFSArbitrationPool: {
 		utils.RecordKey:     "fs_arbitration_pool",
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"xsky.com/sds-formation/parser"
 	"xsky.com/sds-formation/utils"
 )
@@ -34,7 +35,7 @@ func (stringList *StringList) IsReady() (ready bool) {
 }
 
 // Create create the resource
-func (stringList *StringList) Create() (created bool, err error) {
+func (stringList *StringList) Create(ctx context.Context) (created bool, err error) {
 	repr := []string{}
 	for _, attr := range stringList.Attributes {
 		val := stringList.getStringListValue(attr)
@@ -44,3 +45,9 @@ func (stringList *StringList) Create() (created bool, err error) {
 	stringList.repr = repr
 	return true, nil
 }
+
+// Delete delete the resource, nothing to delete since string list is calculated locally
+func (stringList *StringList) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	stringList.repr = repr
+	return true, nil
+}
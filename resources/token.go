@@ -1,11 +1,13 @@
 package formation
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/juju/errors"
 
 	"xsky.com/sds-formation/config"
+	openapiClient "xsky.com/sds-formation/openapi-client"
 	"xsky.com/sds-formation/parser"
 	"xsky.com/sds-formation/utils"
 )
@@ -15,7 +17,10 @@ type Token struct {
 	ResourceBase
 	Name     *parser.StringExpr
 	Email    *parser.StringExpr
-	Password *parser.StringExpr
+	Password *parser.StringExpr `sensitive:"true"`
+
+	// request of creating the token, which is sent again to re-authenticate
+	authData interface{}
 }
 
 // Init inits resource instance
@@ -54,7 +59,7 @@ type authReq struct {
 }
 
 // Create create the resource
-func (token *Token) Create() (created bool, err error) {
+func (token *Token) Create(ctx context.Context) (created bool, err error) {
 	if config.DryRun {
 		return token.fakeCreate()
 	}
@@ -80,19 +85,57 @@ func (token *Token) Create() (created bool, err error) {
 		} `json:"auth"`
 	})
 	data.Auth.Identity.Password = req
-	bytes, err := token.CallCreateAPI(data, nil)
+	bytes, err := token.CallCreateAPI(ctx, data, nil)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
+	uuid, err := parseToken(bytes)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	token.repr = uuid
+	token.authData = data
+
+	return true, nil
+}
+
+// Reauthenticate creates a new token with credentials of the created token, it's used as
+// credential provider of openapi client once the token expires
+func (token *Token) Reauthenticate(ctx context.Context, client openapiClient.Client) (string, error) {
+	if token.authData == nil {
+		return "", errors.Errorf("token is not created")
+	}
+	api, err := settings.GetSetting(token.GetType(), utils.CreateAPIName)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	bytes, err := client.CallCreateAPI(ctx, api, token.authData, nil, nil)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	uuid, err := parseToken(bytes)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return uuid, nil
+}
+
+// parseToken returns uuid of token in response of creating token
+func parseToken(bytes []byte) (string, error) {
 	resp := new(struct {
 		Token struct {
 			UUID string `json:"uuid"`
 		} `json:"token"`
 	})
-	if err = json.Unmarshal(bytes, resp); err != nil {
-		return false, errors.Trace(err)
+	if err := json.Unmarshal(bytes, resp); err != nil {
+		return "", errors.Trace(err)
 	}
-	token.repr = resp.Token.UUID
+	utils.AddSecret(resp.Token.UUID)
+	return resp.Token.UUID, nil
+}
 
+// Delete delete the resource, nothing to delete since token expires by itself
+func (token *Token) Delete(ctx context.Context, repr interface{}) (deleted bool, err error) {
+	token.repr = repr
 	return true, nil
 }
@@ -1,6 +1,7 @@
 package formation
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -32,7 +33,7 @@ type User struct {
 	ResourceBase
 	Name     *parser.StringExpr
 	Email    *parser.StringExpr
-	Password *parser.StringExpr
+	Password *parser.StringExpr `sensitive:"true"`
 	Enabled  *parser.BoolExpr
 }
 
@@ -65,7 +66,7 @@ func (user *User) fakeCreate() (bool, error) {
 }
 
 // Create create the resource
-func (user *User) Create() (created bool, err error) {
+func (user *User) Create(ctx context.Context) (created bool, err error) {
 	if user.Name == nil {
 		err = fmt.Errorf("Name is required for resource %s", user.GetType())
 		return
@@ -75,7 +76,7 @@ func (user *User) Create() (created bool, err error) {
 	}
 
 	name := user.getStringValue(user.Name)
-	resourceID, err := user.getResourceByName(name)
+	resourceID, err := user.getResourceByName(ctx, name)
 	if err != nil {
 		return false, errors.Annotatef(err, "get user %s", name)
 	}
@@ -97,7 +98,7 @@ func (user *User) Create() (created bool, err error) {
 		userInfo.Enabled = user.getBoolValue(user.Enabled)
 	}
 
-	body, err := user.CallCreateAPI(req, nil)
+	body, err := user.CallCreateAPI(ctx, req, nil)
 	if err != nil {
 		return false, errors.Annotatef(err, "create user %s", name)
 	}
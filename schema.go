@@ -0,0 +1,252 @@
+package formation
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/parser"
+	resources "xsky.com/sds-formation/resources"
+	"xsky.com/sds-formation/utils"
+)
+
+// schema is a JSON Schema (draft-07) object
+type schema map[string]interface{}
+
+func refSchema(name string) schema {
+	return schema{"$ref": "#/definitions/" + name}
+}
+
+// names of definitions in schema of template
+const (
+	schemaFunction   = "Function"
+	schemaExpression = "Expression"
+	schemaResource   = "Resource"
+	schemaResources  = "Resources"
+)
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	valueTypes     = []string{
+		parser.ValueTypeBool, parser.ValueTypeBoolList,
+		parser.ValueTypeInteger, parser.ValueTypeIntegerList,
+		parser.ValueTypeString, parser.ValueTypeStringList,
+	}
+)
+
+// TemplateSchema returns JSON Schema of template, which is generated from types of
+// template, resources and functions so that it's always in sync with code
+func TemplateSchema() ([]byte, error) {
+	definitions := schema{}
+	addExpressionSchemas(definitions)
+	addResourceSchemas(definitions)
+
+	parameter := structSchema(reflect.TypeOf(Parameter{}), map[string]schema{
+		"Type": {"enum": valueTypes},
+	})
+	parameter["required"] = []string{"Type"}
+	output := structSchema(reflect.TypeOf(Output{}), map[string]schema{
+		"Type": {"enum": valueTypes},
+	})
+	output["required"] = []string{"Type", "Value"}
+
+	template := structSchema(reflect.TypeOf(Template{}), map[string]schema{
+		"Parameters": mapSchema(parameter),
+		"Conditions": mapSchema(refSchema(parser.ValueTypeBool)),
+		"Mappings":   mapSchema(mapSchema(mapSchema(schema{}))),
+		"Resources":  refSchema(schemaResources),
+		"Templates":  mapSchema(refSchema(schemaResources)),
+		"Outputs":    mapSchema(output),
+	})
+	template["$schema"] = "http://json-schema.org/draft-07/schema#"
+	template["title"] = "sds-formation template"
+	template["definitions"] = definitions
+
+	data, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return data, nil
+}
+
+// addExpressionSchemas adds schemas of functions and expressions, an expression is a literal
+// value or a function returning the value, e.g. {"Ref": "name"}
+func addExpressionSchemas(definitions schema) {
+	functions := schema{}
+	for _, name := range parser.FuncNames() {
+		functions[name] = schema{}
+	}
+	functions[parser.FuncNameRef] = schema{"type": "string"}
+	definitions[schemaFunction] = schema{
+		"type":                 "object",
+		"properties":           functions,
+		"minProperties":        1,
+		"maxProperties":        1,
+		"additionalProperties": false,
+	}
+
+	function := refSchema(schemaFunction)
+	// integers and bools could be represented as strings
+	boolStrings := []string{"1", "t", "T", "TRUE", "true", "True",
+		"0", "f", "F", "FALSE", "false", "False"}
+	definitions[parser.ValueTypeBool] = schema{"anyOf": []schema{
+		{"type": "boolean"}, {"type": "string", "enum": boolStrings}, function,
+	}}
+	definitions[parser.ValueTypeInteger] = schema{"anyOf": []schema{
+		{"type": "integer"}, {"type": "string", "pattern": "^[-+]?[0-9]+$"}, function,
+	}}
+	definitions[parser.ValueTypeString] = schema{"anyOf": []schema{
+		{"type": "string"}, function,
+	}}
+	// single item could be used as a list
+	for _, itemType := range []string{parser.ValueTypeBool, parser.ValueTypeInteger,
+		parser.ValueTypeString} {
+
+		definitions[itemType+"List"] = schema{"anyOf": []schema{
+			{"type": "array", "items": refSchema(itemType)}, refSchema(itemType),
+		}}
+	}
+
+	expressions := make([]schema, 0, len(valueTypes))
+	for _, valueType := range valueTypes {
+		expressions = append(expressions, refSchema(valueType))
+	}
+	definitions[schemaExpression] = schema{"anyOf": expressions}
+}
+
+// addResourceSchemas adds schemas of resources, properties of resources are checked by
+// their types
+func addResourceSchemas(definitions schema) {
+	var typeNames []string
+	var propertiesSchemas []schema
+	addProperties := func(typeName string, properties utils.ResourceInterface, action schema) {
+		name := indirectType(reflect.TypeOf(properties)).Name() + "Properties"
+		definitions[name] = structSchema(reflect.TypeOf(properties), nil)
+		condition := schema{"Type": schema{"const": typeName}}
+		required := []string{"Type"}
+		if action != nil {
+			condition["Action"] = action
+			// properties without Action are matched by the other condition
+			if _, ok := action["const"]; ok {
+				required = append(required, "Action")
+			}
+		}
+		propertiesSchemas = append(propertiesSchemas, schema{
+			"if":   schema{"properties": condition, "required": required},
+			"then": schema{"properties": schema{"Properties": refSchema(name)}},
+		})
+	}
+	for _, typeName := range resources.ResourceTypes() {
+		typeNames = append(typeNames, typeName)
+		if typeName == utils.ResourceTemplate {
+			continue
+		}
+		// resource with Update action could be a different type, e.g. DiskList
+		properties := resources.NewResource(typeName, "")
+		updateProperties := resources.NewResource(typeName, utils.ActionTypeUpdate)
+		if reflect.TypeOf(properties) == reflect.TypeOf(updateProperties) {
+			addProperties(typeName, properties, nil)
+			continue
+		}
+		addProperties(typeName, updateProperties, schema{"const": utils.ActionTypeUpdate})
+		addProperties(typeName, properties,
+			schema{"not": schema{"const": utils.ActionTypeUpdate}})
+	}
+
+	context := structSchema(reflect.TypeOf(templateContext{}), map[string]schema{
+		"Type":   {"enum": valueTypes},
+		"Action": {"enum": []string{utils.ContextValueActionRange}},
+	})
+	context["required"] = []string{"Name", "Type", "Value"}
+
+	resource := structSchema(reflect.TypeOf(ResourceInTemplate{}), map[string]schema{
+		"Type": {"enum": typeNames},
+		"Action": {"enum": []string{utils.ActionTypeCreate, utils.ActionTypeGet,
+			utils.ActionTypeUpdate}},
+		"Context":    {"type": "array", "items": context},
+		"Properties": {"type": "object"},
+	})
+	resource["required"] = []string{"Name", "Type"}
+	propertiesSchemas = append(propertiesSchemas, schema{
+		"if": schema{
+			"properties": schema{"Type": schema{"const": utils.ResourceTemplate}},
+			"required":   []string{"Type"},
+		},
+		"then": schema{"required": []string{"TemplateName"}},
+		"else": schema{"required": []string{"Properties"}},
+	})
+	resource["allOf"] = propertiesSchemas
+	definitions[schemaResource] = resource
+	definitions[schemaResources] = schema{"type": "array", "items": refSchema(schemaResource)}
+}
+
+// structSchema returns schema of object with public fields of the struct, schemas of fields
+// could be overridden
+func structSchema(typ reflect.Type, overrides map[string]schema) schema {
+	typ = indirectType(typ)
+	properties := schema{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		// skip ResourceBase and private fields
+		if field.Anonymous || field.PkgPath != "" {
+			continue
+		}
+		if override, ok := overrides[field.Name]; ok {
+			properties[field.Name] = override
+			continue
+		}
+		properties[field.Name] = typeSchema(field.Type)
+	}
+	return schema{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func mapSchema(items schema) schema {
+	return schema{"type": "object", "additionalProperties": items}
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// typeSchema returns schema of values of the type, expressions are referenced by their types
+func typeSchema(typ reflect.Type) schema {
+	if typ == rawMessageType {
+		return schema{}
+	}
+	for _, valueType := range valueTypes {
+		if typ == reflect.TypeOf(parser.NewExpr(valueType)) {
+			return refSchema(valueType)
+		}
+	}
+	if typ.Kind() == reflect.Interface && typ.Implements(exprType) {
+		return refSchema(schemaExpression)
+	}
+
+	typ = indirectType(typ)
+	switch typ.Kind() {
+	case reflect.Struct:
+		return structSchema(typ, nil)
+	case reflect.Slice:
+		return schema{"type": "array", "items": typeSchema(typ.Elem())}
+	case reflect.Map:
+		return mapSchema(typeSchema(typ.Elem()))
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	}
+	return schema{}
+}
@@ -0,0 +1,71 @@
+package formation
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"xsky.com/sds-formation/parser"
+	resources "xsky.com/sds-formation/resources"
+	"xsky.com/sds-formation/utils"
+)
+
+type schemaSuite struct {
+	suite.Suite
+
+	schema map[string]interface{}
+}
+
+func (s *schemaSuite) SetupTest() {
+	data, err := TemplateSchema()
+	s.NoError(err)
+	s.NoError(json.Unmarshal(data, &s.schema))
+}
+
+func (s *schemaSuite) definition(name string) map[string]interface{} {
+	definitions := s.schema["definitions"].(map[string]interface{})
+	definition, _ := definitions[name].(map[string]interface{})
+	return definition
+}
+
+func (s *schemaSuite) TestResources() {
+	for _, typeName := range resources.ResourceTypes() {
+		if typeName == utils.ResourceTemplate {
+			continue
+		}
+		r := resources.NewResource(typeName, "")
+		typ := reflect.TypeOf(r).Elem()
+		definition := s.definition(typ.Name() + "Properties")
+		s.NotNil(definition, typeName)
+		properties := definition["properties"].(map[string]interface{})
+		// all public fields of resources are properties in schema
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Field(i); !field.Anonymous && field.PkgPath == "" {
+				s.Contains(properties, field.Name, typeName)
+			}
+		}
+	}
+	s.NotNil(s.definition("DiskListUpdateProperties"))
+
+	properties := s.definition("PoolProperties")["properties"].(map[string]interface{})
+	s.Equal(map[string]interface{}{"$ref": "#/definitions/Integer"}, properties["Size"])
+	s.Equal(map[string]interface{}{"$ref": "#/definitions/IntegerList"}, properties["OsdIDs"])
+
+	resource := s.definition("Resource")["properties"].(map[string]interface{})
+	s.Contains(resource["Type"].(map[string]interface{})["enum"], utils.ResourcePool)
+}
+
+func (s *schemaSuite) TestFunctions() {
+	properties := s.definition("Function")["properties"].(map[string]interface{})
+	s.Len(properties, len(parser.FuncNames()))
+	for _, name := range parser.FuncNames() {
+		s.Contains(properties, name)
+	}
+	s.Equal(map[string]interface{}{"type": "string"}, properties[parser.FuncNameRef])
+}
+
+func TestSchemaSuite(t *testing.T) {
+	suite.Run(t, new(schemaSuite))
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"container/list"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +13,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
@@ -19,6 +25,7 @@ import (
 	"xsky.com/sds-formation/config"
 	openapiClient "xsky.com/sds-formation/openapi-client"
 	"xsky.com/sds-formation/parser"
+	resources "xsky.com/sds-formation/resources"
 	"xsky.com/sds-formation/utils"
 )
 
@@ -36,11 +43,56 @@ type Stack struct {
 	templateContext  map[string]interface{}
 	valueContexts    list.List
 	template         *Template
-	inTmpl           bool
-	cacheIndex       int
+	tmplDepth        int
 	cacheExprs       []*CacheRecord
 	cacheFile        io.ReadWriteCloser
 	cacheFilePath    string
+	state            *State
+	statePath        string
+	stateIndex       int
+	planEntries      []*planEntry
+	// cache records by keys of resources, records are written in order of resources except
+	// resources done after a failed one
+	cacheRecords map[string]*CacheRecord
+	// templatePath is path of the template instance in progress, e.g. racks[0].hosts[1], it's
+	// shown in plan and used in keys of resources
+	templatePath string
+	// values of conditions are evaluated on init
+	conditionValues      map[string]bool
+	evaluatingConditions map[string]bool
+	// lock protects resource values written by resources created in parallel
+	lock sync.RWMutex
+	// stopped is set to 1 by Stop, no more resources are handled once it's set
+	stopped int32
+}
+
+// actions of resources shown in plan
+const (
+	planActionCreate   = "create"
+	planActionAdopt    = "adopt"
+	planActionUpdate   = "update"
+	planActionNoChange = "no-change"
+	planActionRead     = "read"
+	planActionCached   = "cached"
+	planActionSkip     = "skip"
+)
+
+// actionSkipped is action recorded for resources skipped since their conditions are false
+const actionSkipped = "Skipped"
+
+// actionAdopted is action recorded for existing resources reused instead of being created,
+// they are not deleted when the stack is destroyed
+const actionAdopted = "Adopted"
+
+type planEntry struct {
+	Name     string
+	Type     string
+	Template string
+	Action   string
+	Requests []*utils.PlannedRequest
+	// KnownAfterApply is set if the resource references resources to be created, whose ids
+	// are faked in plan, so its requests and values are only known after apply
+	KnownAfterApply bool
 }
 
 func (s *Stack) loadCache(name string) error {
@@ -61,6 +113,35 @@ func (s *Stack) loadCache(name string) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if s.cacheExprs, err = readCacheRecords(cacheData); err != nil {
+		return errors.Trace(err)
+	}
+	s.cacheRecords = make(map[string]*CacheRecord, len(s.cacheExprs))
+	for _, cacheRecord := range s.cacheExprs {
+		// records written by former versions don't have keys, resources of them are got again
+		if cacheRecord.Key == "" {
+			log.Printf("%s without key is ignored", cacheRecord)
+			continue
+		}
+		s.cacheRecords[cacheRecord.Key] = cacheRecord
+	}
+	if len(s.cacheExprs) != 0 {
+		log.Printf("Load %d resource cache record(s) from %s\n", len(s.cacheExprs), s.cacheFilePath)
+	}
+	return nil
+}
+
+// resourceKey returns key of the resource at index of the template instance in progress,
+// e.g. racks[0].hosts[1].2, which is the same between runs of the same template
+func (s *Stack) resourceKey(index int) string {
+	if s.templatePath == "" {
+		return strconv.Itoa(index)
+	}
+	return fmt.Sprintf("%s.%d", s.templatePath, index)
+}
+
+func readCacheRecords(cacheData []byte) ([]*CacheRecord, error) {
+	var cacheRecords []*CacheRecord
 	reader := bufio.NewReader(bytes.NewReader(cacheData))
 	for {
 		line, err := reader.ReadBytes('\n')
@@ -68,47 +149,67 @@ func (s *Stack) loadCache(name string) error {
 			if err == io.EOF {
 				break
 			}
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
 		cacheRecord := new(CacheRecord)
 		if err = json.Unmarshal(line, cacheRecord); err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
-		s.cacheExprs = append(s.cacheExprs, cacheRecord)
+		cacheRecords = append(cacheRecords, cacheRecord)
 	}
-	if len(s.cacheExprs) != 0 {
-		log.Printf("Load %d resource cache record(s) from %s\n", len(s.cacheExprs), s.cacheFilePath)
+	return cacheRecords, nil
+}
+
+func (s *Stack) loadState(name, clusterURL string) (err error) {
+	s.statePath = config.StateFile
+	if s.statePath == "" {
+		s.statePath = filepath.Join(config.CachePath, name+".state.json")
+	}
+	if s.state, err = LoadState(s.statePath); err != nil {
+		return errors.Trace(err)
+	}
+	if s.state == nil {
+		s.state = NewState(s.template.Description, clusterURL)
+	} else {
+		log.Printf("Load state of %d resource(s) from %s\n", len(s.state.Resources), s.statePath)
 	}
 	return nil
 }
 
 // Init initialize the stack
-func (s *Stack) Init(filePath string) (err error) {
+func (s *Stack) Init(ctx context.Context, filePath string) (err error) {
 	s.resourceValueMap = make(map[string]interface{})
 	s.template = new(Template)
 
-	file, err := OpenFile(filePath, os.O_RDONLY)
+	out, err := readTemplateFile(filePath)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	out, err := ioutil.ReadAll(file)
+	err = json.Unmarshal(out, s.template)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	if err = file.Close(); err != nil {
+	if err = s.template.loadImports(filepath.Dir(filePath)); err != nil {
 		return errors.Trace(err)
 	}
-	err = json.Unmarshal(out, s.template)
+	err = s.template.CheckTemplates()
 	if err != nil {
 		return errors.Trace(err)
 	}
-	err = s.template.CheckTemplates()
+
+	utils.AddSecret(config.Token)
+	err = OverrideParameters(s.template.Parameters, config.ParamFile, config.Params)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if err = ValidateParameters(s.template.Parameters); err != nil {
+		return errors.Trace(err)
+	}
+	for _, param := range s.template.Parameters {
+		param.AddSecrets()
+	}
 
 	var clusterURL string
-	// TODO(wuhao): currently we only support using default as value of parameter.
 	for key, param := range s.template.Parameters {
 		ok := true
 		switch key {
@@ -124,6 +225,9 @@ func (s *Stack) Init(filePath string) (err error) {
 	if clusterURL == "" {
 		log.Fatalf("%s is required", utils.ParamClusterURL)
 	}
+	if err = s.evaluateConditions(); err != nil {
+		return errors.Trace(err)
+	}
 
 	templateHash, err := utils.GetHashString([]byte(s.template.Description + clusterURL))
 	if err != nil {
@@ -132,12 +236,31 @@ func (s *Stack) Init(filePath string) (err error) {
 	if err = s.loadCache(templateHash); err != nil {
 		return errors.Trace(err)
 	}
+	if err = s.loadState(templateHash, clusterURL); err != nil {
+		return errors.Trace(err)
+	}
 
-	s.openapiClient = openapiClient.NewOpenAPIClient()
+	s.openapiClient = openapiClient.NewOpenAPIClient(&openapiClient.TLSOptions{
+		CAFile:             config.CAFile,
+		CertFile:           config.CertFile,
+		KeyFile:            config.KeyFile,
+		ServerName:         config.TLSServerName,
+		InsecureSkipVerify: config.Insecure,
+	})
 	s.openapiClient.SetServer(clusterURL)
 	s.openapiClient.SetToken(config.Token)
-	s.openapiClient.Init()
-	if err = s.openapiClient.LoadSpec(); err != nil {
+	s.openapiClient.SetTimeout(config.RequestTimeout)
+	s.openapiClient.SetSpecFile(config.OpenAPISpec)
+	s.openapiClient.SetSpecCacheDir(filepath.Join(config.CachePath, "openapi"))
+	s.openapiClient.SetRetryPolicy(openapiClient.RetryPolicy{
+		MaxRetries: config.Retries,
+		BaseDelay:  config.RetryDelay,
+		MaxDelay:   config.RetryMaxDelay,
+	})
+	if err = s.openapiClient.Init(); err != nil {
+		return errors.Trace(err)
+	}
+	if err = s.openapiClient.LoadSpec(ctx); err != nil {
 		return errors.Trace(err)
 	}
 
@@ -145,15 +268,18 @@ func (s *Stack) Init(filePath string) (err error) {
 	for _, r := range s.template.Resources {
 		r.Properties.Init(s)
 	}
+	if _, err = buildResourceGraph(s.template.Resources); err != nil {
+		return errors.Trace(err)
+	}
 
 	return
 }
 
 // CallAPI calls openapi api with operation id
-func (s *Stack) CallAPI(api string, req interface{}, pathParam map[string]string,
-	urlParam ...map[string]string) ([]byte, error) {
+func (s *Stack) CallAPI(ctx context.Context, api string, req interface{},
+	pathParam map[string]string, urlParam ...map[string]string) ([]byte, error) {
 
-	bytes, err := s.GetOpenAPIClient().CallAPI(api, req, pathParam, urlParam...)
+	bytes, err := s.GetOpenAPIClient().CallAPI(ctx, api, req, pathParam, urlParam...)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -167,18 +293,11 @@ func (s *Stack) GetOpenAPIClient() openapiClient.Client {
 
 func (s *Stack) getCreatingResources() []string {
 	creatingResources := []string{}
-	cacheIndex := 0
-	for _, r := range s.template.Resources {
+	for i, r := range s.template.Resources {
 		name := r.Name
-		for cacheIndex < len(s.cacheExprs) && s.cacheExprs[cacheIndex].InTemplate {
-			cacheIndex++
-		}
-		if cacheIndex < len(s.cacheExprs) && name == r.Name && r.Type != utils.ResourceToken {
+		if _, ok := s.cacheRecords[s.resourceKey(i)]; ok && r.Type != utils.ResourceToken {
 			name += "(cached)"
 		}
-		if r.Type != utils.ResourceToken {
-			cacheIndex++
-		}
 		creatingResources = append(creatingResources, name)
 	}
 	return creatingResources
@@ -240,6 +359,14 @@ func (s *Stack) CheckGetTemplateResourceContext(resource *ResourceInTemplate) ([
 			if context.Action == utils.ContextValueActionRange {
 				rangeType = true
 			}
+		case *parser.BoolListExpr:
+			value, err = val.GetValue(s)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if context.Action == utils.ContextValueActionRange {
+				rangeType = true
+			}
 		}
 		if rangeType {
 			contextValue := reflect.ValueOf(value)
@@ -277,43 +404,73 @@ func (s *Stack) CheckGetTemplateResourceContext(resource *ResourceInTemplate) ([
 	return contextList, nil
 }
 
-func (s *Stack) restoreCache(resource *ResourceInTemplate) (bool, error) {
+// restoreCache restores value of the resource with the key from cache, index of state is
+// moved on when the result is committed
+func (s *Stack) restoreCache(resource *ResourceInTemplate, key string, skipped bool) (bool, error) {
 	// do not cache token record and do not restore token cache record
-	if s.cacheIndex >= len(s.cacheExprs) || resource.Type == utils.ResourceToken {
+	if resource.Type == utils.ResourceToken {
 		return false, nil
 	}
-	cacheExpr := s.cacheExprs[s.cacheIndex]
-	if resource.Name != cacheExpr.Name || resource.Type != cacheExpr.ResourceType {
-		return false, errors.Errorf("got invalid cache record %s for resource %s, index %d",
-			cacheExpr, resource.Name, s.cacheIndex)
+	cacheExpr, ok := s.cacheRecords[key]
+	if !ok {
+		return false, nil
+	}
+	if resource.Name != cacheExpr.Name || resource.Type != cacheExpr.ResourceType ||
+		s.tmplDepth != cacheExpr.GetDepth() {
+
+		return false, errors.Errorf("got invalid cache record %s for resource %s, key %s",
+			cacheExpr, resource.Name, key)
+	}
+	// value of condition changes if parameters change between runs
+	if (cacheExpr.Action == actionSkipped) != skipped {
+		return false, errors.Errorf("condition %s of resource %s changes since last run, "+
+			"run with -no-continue to start over", resource.Condition, resource.Name)
+	}
+	if skipped {
+		return true, nil
 	}
 	cacheVal, err := cacheExpr.GetExpr()
 	if err != nil {
 		return false, errors.Trace(err)
 	}
-	s.resourceValueMap[resource.Name] = cacheVal
-	s.cacheIndex++
+	s.setResourceValue(resource.Name, cacheVal)
 	return true, nil
 }
 
-func (s *Stack) createResourcesWithTemplate(r *ResourceInTemplate) (interface{}, bool, error) {
-	s.inTmpl = true
+func (s *Stack) createResourcesWithTemplate(ctx context.Context, r *ResourceInTemplate,
+	key string) (interface{}, bool, error) {
+
+	s.tmplDepth++
 	defer func() {
-		s.inTmpl = false
+		s.tmplDepth--
 	}()
 	templateContextes, err := s.CheckGetTemplateResourceContext(r)
 	if err != nil {
 		return nil, false, errors.Trace(err)
 	}
-	log.SetPrefix(fmt.Sprintf("[template resource: %s]+", r.Name))
+	module := s.template.modules[r.TemplateName]
+	if module != nil {
+		for _, context := range templateContextes {
+			if err = module.setParameters(context); err != nil {
+				return nil, false, errors.Annotatef(err, "template resource %s", r.Name)
+			}
+		}
+	}
+	logPrefix, templatePath := log.Prefix(), s.templatePath
+	log.SetPrefix(fmt.Sprintf("%s[template resource: %s]+", logPrefix, r.Name))
 	defer func() {
-		log.SetPrefix("")
+		log.SetPrefix(logPrefix)
+		s.templatePath = templatePath
 	}()
+	// resources in nested template are shown as rack[0].host[1] in plan
+	pathPrefix := r.Name
+	if templatePath != "" {
+		pathPrefix = templatePath + "." + r.Name
+	}
 	templateValues := make([]map[string]interface{}, 0, len(templateContextes))
-	for _, context := range templateContextes {
-		s.pushContext(s.resourceValueMap)
-		s.templateContext = context
-		s.resourceValueMap = map[string]interface{}{}
+	for i, context := range templateContextes {
+		s.templatePath = fmt.Sprintf("%s[%d]", pathPrefix, i)
+		s.pushContext(context)
 		templateData := s.getTemplate(r.TemplateName)
 		if templateData == nil {
 			return nil, false, errors.Errorf("tempalte with name %s not found", r.TemplateName)
@@ -325,113 +482,443 @@ func (s *Stack) createResourcesWithTemplate(r *ResourceInTemplate) (interface{},
 		for _, resource := range resources {
 			resource.Properties.Init(s)
 		}
-		if err := s.CreateResources(resources); err != nil {
+		if err := s.CreateResources(ctx, resources); err != nil {
 			return nil, false, errors.Trace(err)
 		}
+		if module != nil {
+			// outputs of module are exported as values of the template resource
+			outputs, err := module.getOutputs(s)
+			if err != nil {
+				return nil, false, errors.Trace(err)
+			}
+			for name, value := range outputs {
+				s.setResourceValue(name, value)
+			}
+		}
 		templateValues = append(templateValues, s.resourceValueMap)
-		s.resourceValueMap = s.popContext()
-		s.templateContext = nil
+		s.popContext()
 	}
-	restored, err := s.restoreCache(r)
+	restored, err := s.restoreCache(r, key, false)
 	if err != nil {
 		return nil, false, errors.Trace(err)
 	}
 	if restored {
 		return nil, true, nil
 	}
-	s.resourceValueMap[r.Name] = templateValues
+	s.setResourceValue(r.Name, templateValues)
 	return templateValues, false, nil
 }
 
-// CreateResources create resources with Resource Template
-func (s *Stack) CreateResources(resources []*ResourceInTemplate) error {
-	for _, r := range resources {
-		var repr interface{}
-		var rType string
-		if r.Type == utils.ResourceTemplate {
-			tmplRepr, restored, err := s.createResourcesWithTemplate(r)
-			if err != nil {
-				return errors.Trace(err)
-			}
-			if restored {
-				continue
+// CreateResources create resources with Resource Template, resources are created in parallel
+// according to their dependencies and resources recorded in cache are restored by their keys.
+// Resources in progress are finished and recorded if a resource fails, the stack is stopped or
+// the context is done, but no more resources are handled.
+func (s *Stack) CreateResources(ctx context.Context, resources []*ResourceInTemplate) error {
+	nodes, err := buildResourceGraph(resources)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = runResourceGraph(nodes, 0, config.Workers,
+		func(index int) *resourceResult {
+			return s.createResource(ctx, index, resources[index])
+		},
+		func(result *resourceResult) {
+			s.commitResource(resources[result.index], result)
+		})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+func (s *Stack) createResource(ctx context.Context, index int, r *ResourceInTemplate) *resourceResult {
+	result := &resourceResult{index: index, key: s.resourceKey(index), action: r.Action}
+	if err := s.checkStopped(ctx); err != nil {
+		result.err = errors.Annotatef(err, "resource %s is not handled", r.Name)
+		return result
+	}
+	if result.action == "" {
+		result.action = utils.ActionTypeCreate
+	}
+	if r.Condition != "" {
+		condition, err := s.GetCondition(r.Condition)
+		if err != nil {
+			result.err = errors.Annotatef(err, "resource %s", r.Name)
+			return result
+		}
+		if !condition {
+			if result.restored, err = s.restoreCache(r, result.key, true); err != nil {
+				result.err = errors.Trace(err)
+				return result
 			}
-			repr = tmplRepr
-			rType = utils.ResourceTemplate
+			log.Printf("resource %s is skipped since condition %s is false", r.Name, r.Condition)
+			result.skipped, result.action, result.rType = true, actionSkipped, r.Type
+			return result
+		}
+	}
+	if r.Type == utils.ResourceTemplate {
+		tmplRepr, restored, err := s.createResourcesWithTemplate(ctx, r, result.key)
+		if err != nil {
+			result.err = errors.Trace(err)
+			return result
+		}
+		if !restored {
 			log.Printf("template resource %s has been created successfully!!!", r.Name)
-		} else {
-			restored, err := s.restoreCache(r)
-			if err != nil {
-				return errors.Trace(err)
-			}
-			if restored {
-				continue
-			}
-			name, resource := r.Name, r.Properties
-			if !resource.IsReady() {
-				log.Fatalf("resources %v can't be created for lack of required resources", name)
-			}
+		}
+		result.restored, result.repr, result.rType = restored, tmplRepr, utils.ResourceTemplate
+		return result
+	}
 
-			switch r.Action {
-			case utils.ActionTypeUpdate:
-				err := s.handleUpdate(name, resource, r.WaitInterval, r.CheckInterval)
-				if err != nil {
-					log.Fatalf("update resource %s: %s", name, errors.ErrorStack(err))
-				}
-			case utils.ActionTypeGet:
-				err := s.handleGet(name, resource, r.WaitInterval, r.CheckInterval)
-				if err != nil {
-					log.Fatalf("get resource %s: %s", name, errors.ErrorStack(err))
-				}
-				s.resourceValueMap[name] = resource.Repr()
-			default:
-				err := s.handleCreate(name, resource, r.WaitInterval, r.CheckInterval)
-				if err != nil {
-					log.Fatalf("create resource %s: %s", name, errors.ErrorStack(err))
-				}
-				s.resourceValueMap[name] = resource.Repr()
-			}
-			if r.Sleep > 0 {
-				log.Printf("sleep %d seconds", r.Sleep)
-				time.Sleep(time.Duration(r.Sleep) * time.Second)
-			}
-			repr = r.Properties.Repr()
-			rType = r.Properties.GetType()
+	restored, err := s.restoreCache(r, result.key, false)
+	if err != nil {
+		result.err = errors.Trace(err)
+		return result
+	}
+	if restored {
+		result.restored = true
+		return result
+	}
+	name, resource := r.Name, r.Properties
+	if !resource.IsReady() {
+		result.err = errors.Errorf("resources %v can't be created for lack of required resources", name)
+		return result
+	}
+	// properties are resolved before handling so that sensitive values are redacted in logs
+	if result.properties, err = resource.ResolveProperties(); err != nil {
+		result.err = errors.Trace(err)
+		return result
+	}
+
+	switch r.Action {
+	case utils.ActionTypeUpdate:
+		if err = s.handleUpdate(ctx, name, resource, r.WaitInterval, r.CheckInterval); err != nil {
+			result.err = errors.Annotatef(err, "update resource %s", name)
+			return result
 		}
-		if err := s.record(r.Name, rType, repr); err != nil {
-			log.Fatal(errors.ErrorStack(err))
+	case utils.ActionTypeGet:
+		if err = s.handleGet(ctx, name, resource, r.WaitInterval, r.CheckInterval); err != nil {
+			result.err = errors.Annotatef(err, "get resource %s", name)
+			return result
+		}
+		s.setResourceValue(name, resource.Repr())
+	default:
+		if err = s.handleCreate(ctx, name, resource, r.WaitInterval, r.CheckInterval); err != nil {
+			result.err = errors.Annotatef(err, "create resource %s", name)
+			return result
+		}
+		if resource.Adopted() {
+			result.action = actionAdopted
+		}
+		result.adoptedItems = resource.AdoptedItems()
+		s.setResourceValue(name, resource.Repr())
+	}
+	if r.Sleep > 0 && !config.Plan {
+		log.Printf("sleep %d seconds", r.Sleep)
+		if err = utils.Sleep(ctx, time.Duration(r.Sleep)*time.Second); err != nil {
+			result.err = errors.Annotatef(err, "sleep after resource %s", name)
+			return result
 		}
 	}
+	result.repr = resource.Repr()
+	result.rType = resource.GetType()
+	return result
+}
+
+// commitResource records result of the resource, it's called in order of resources
+func (s *Stack) commitResource(r *ResourceInTemplate, result *resourceResult) {
+	if r.Type != utils.ResourceTemplate || result.skipped {
+		s.planResource(r, result)
+	}
+	if result.restored {
+		// state of restored resource has been recorded at the index
+		s.stateIndex++
+		return
+	}
+	err := s.record(result.key, r.Name, result.rType, result.action, result.repr, result.adoptedItems,
+		result.properties)
+	if err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+}
+
+// Stop stops the stack gracefully, resources in progress are finished and recorded so that
+// the stack could be continued from them, but no more resources are handled
+func (s *Stack) Stop() {
+	atomic.StoreInt32(&s.stopped, 1)
+}
 
+// checkStopped returns error if the stack is stopped or the context is done
+func (s *Stack) checkStopped(ctx context.Context) error {
+	if atomic.LoadInt32(&s.stopped) != 0 {
+		return errors.New("stack is stopped")
+	}
+	if err := ctx.Err(); err != nil {
+		return errors.Trace(err)
+	}
 	return nil
 }
 
+func (s *Stack) setResourceValue(name string, value interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.resourceValueMap[name] = value
+}
+
 // Create create resource in the stack
-func (s *Stack) Create() {
+func (s *Stack) Create(ctx context.Context) {
 	log.Printf("Stack started create resources: %+v", s.getCreatingResources())
 
-	if err := s.CreateResources(s.template.Resources); err != nil {
-		log.Fatal(err)
+	if err := s.CreateResources(ctx, s.template.Resources); err != nil {
+		log.Fatal(errors.ErrorStack(err))
 	}
 
 	if e := s.cacheFile.Close(); e != nil {
 		log.Println(errors.Annotate(e, "close cache file"))
 	}
-	if e := os.Remove(s.cacheFilePath); e != nil {
-		log.Println(errors.Annotate(e, "remove cache file"))
+	// cache and state of former runs are kept in dry run
+	if !config.DryRun {
+		if e := os.Remove(s.cacheFilePath); e != nil {
+			log.Println(errors.Annotate(e, "remove cache file"))
+		}
+		log.Printf("Stack state of %d resource(s) is saved in %s", len(s.state.Resources), s.statePath)
+	}
+
+	if len(s.template.Outputs) == 0 {
+		return
+	}
+	outputs, err := s.GetOutputs()
+	if err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	printOutputs(utils.NewRedactWriter(os.Stdout), outputs)
+	if config.OutputFile != "" {
+		if err = writeOutputs(config.OutputFile, outputs); err != nil {
+			log.Fatalf("write outputs to %s: %s", config.OutputFile, errors.ErrorStack(err))
+		}
+		log.Printf("Stack outputs are written to %s", config.OutputFile)
 	}
 
 	return
 }
 
-func (s *Stack) record(resourceName, resourceType string, value interface{}) error {
-	if resourceType == utils.ResourceToken {
+// Plan gets resources from server and shows what would be done to resources of the stack
+// without changing them
+func (s *Stack) Plan(ctx context.Context) {
+	log.Printf("Stack started plan resources: %+v", s.getCreatingResources())
+
+	if err := s.CreateResources(ctx, s.template.Resources); err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+	if e := s.cacheFile.Close(); e != nil {
+		log.Println(errors.Annotate(e, "close cache file"))
+	}
+
+	if err := s.printPlan(utils.NewRedactWriter(os.Stdout)); err != nil {
+		log.Fatal(errors.ErrorStack(err))
+	}
+}
+
+func (s *Stack) planResource(r *ResourceInTemplate, result *resourceResult) {
+	if !config.Plan {
+		return
+	}
+	entry := &planEntry{
+		Name:     r.Name,
+		Type:     r.Type,
+		Template: s.templatePath,
+	}
+	if result.skipped {
+		entry.Action = planActionSkip
+		s.planEntries = append(s.planEntries, entry)
+		return
+	}
+	entry.Requests = r.Properties.PlannedRequests()
+	entry.KnownAfterApply = s.referencesPlanned(r.Properties.References())
+	switch {
+	case result.restored:
+		entry.Action = planActionCached
+	case r.Action == utils.ActionTypeGet:
+		entry.Action = planActionRead
+	case r.Action == utils.ActionTypeUpdate:
+		entry.Action = planActionNoChange
+		if len(entry.Requests) != 0 {
+			entry.Action = planActionUpdate
+		}
+	case r.Type == utils.ResourceToken || !resources.IsCreatable(r.Type):
+		entry.Action = planActionRead
+	case len(entry.Requests) != 0:
+		entry.Action = planActionCreate
+	default:
+		entry.Action = planActionAdopt
+	}
+	s.planEntries = append(s.planEntries, entry)
+}
+
+// referencesPlanned returns whether any of the names refers to a resource planned to be created
+// or known after apply, resources of the current template and templates containing it are
+// looked up. Values passed by contexts of template resources are not tracked.
+func (s *Stack) referencesPlanned(names []string) bool {
+	referenced := map[string]bool{}
+	for _, name := range names {
+		referenced[name] = true
+	}
+	for _, entry := range s.planEntries {
+		if !referenced[entry.Name] ||
+			(entry.Action != planActionCreate && !entry.KnownAfterApply) {
+			continue
+		}
+		if entry.Template == "" || entry.Template == s.templatePath ||
+			strings.HasPrefix(s.templatePath, entry.Template+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Stack) printPlan(w io.Writer) error {
+	fmt.Fprintln(w, "Parameters:")
+	paramNames := make([]string, 0, len(s.template.Parameters))
+	for name := range s.template.Parameters {
+		paramNames = append(paramNames, name)
+	}
+	sort.Strings(paramNames)
+	for _, name := range paramNames {
+		param := s.template.Parameters[name]
+		var value interface{} = utils.RedactedValue
+		if !param.NoEcho {
+			value = param.Value
+		}
+		fmt.Fprintf(w, "    %s = %v\n", name, value)
+	}
+
+	counts := map[string]int{}
+	fmt.Fprintln(w, "Resources:")
+	for _, entry := range s.planEntries {
+		counts[entry.Action]++
+		name := entry.Name
+		if entry.Template != "" {
+			name = entry.Template + "." + name
+		}
+		fmt.Fprintf(w, "    %-9s %s (%s)", entry.Action, name, entry.Type)
+		if entry.KnownAfterApply {
+			fmt.Fprint(w, " known after apply")
+		}
+		fmt.Fprintln(w)
+		for _, req := range entry.Requests {
+			fmt.Fprintf(w, "        %s", req.API)
+			if len(req.PathParams) != 0 {
+				fmt.Fprintf(w, " %v", req.PathParams)
+			}
+			fmt.Fprintln(w)
+			if len(req.Body) == 0 {
+				continue
+			}
+			var body bytes.Buffer
+			if err := json.Indent(&body, req.Body, "        ", "    "); err != nil {
+				return errors.Trace(err)
+			}
+			fmt.Fprintf(w, "        %s\n", body.String())
+		}
+	}
+	fmt.Fprintf(w, "Plan: %d to create, %d to adopt, %d to update, %d to read, %d cached, %d skipped\n",
+		counts[planActionCreate], counts[planActionAdopt], counts[planActionUpdate],
+		counts[planActionRead], counts[planActionCached], counts[planActionSkip])
+	return nil
+}
+
+// Destroy delete resources recorded in the stack state in reverse order of creation
+func (s *Stack) Destroy(ctx context.Context) {
+	resourceStates := s.state.Resources
+	if len(resourceStates) == 0 {
+		log.Printf("No resource found in state of the stack, nothing to destroy")
+	} else {
+		log.Printf("Stack started delete %d resource(s)", len(resourceStates))
+	}
+
+	for _, r := range s.template.Resources {
+		if r.Type != utils.ResourceToken {
+			continue
+		}
+		if !r.Properties.IsReady() {
+			log.Fatalf("resources %v can't be created for lack of required resources", r.Name)
+		}
+		// properties are resolved so that sensitive values are redacted in logs
+		if _, err := r.Properties.ResolveProperties(); err != nil {
+			log.Fatalf("resolve properties of resource %s: %s", r.Name, errors.ErrorStack(err))
+		}
+		err := s.handleCreate(ctx, r.Name, r.Properties, r.WaitInterval, r.CheckInterval)
+		if err != nil {
+			log.Fatalf("create resource %s: %s", r.Name, errors.ErrorStack(err))
+		}
+	}
+
+	for i := len(resourceStates) - 1; i >= 0; i-- {
+		// resources deleted are removed from the state, so it could be destroyed again
+		if err := s.checkStopped(ctx); err != nil {
+			log.Fatalf("%d resource(s) are not deleted: %s", i+1, errors.ErrorStack(err))
+		}
+		resourceState := resourceStates[i]
+		if resourceState.Action == actionAdopted {
+			log.Printf("resource %s is kept since it existed before the stack", resourceState.Name)
+		}
+		// resources created in template are recorded separately before the template resource
+		if resourceState.ResourceType != utils.ResourceTemplate &&
+			resourceState.Action == utils.ActionTypeCreate {
+
+			resource := resources.NewResource(resourceState.ResourceType, resourceState.Action)
+			if resource == nil {
+				log.Fatalf("unknown resource type %s of %s",
+					resourceState.ResourceType, &resourceState.CacheRecord)
+			}
+			resource.Init(s)
+			repr, err := resourceState.GetCreatedExpr()
+			if err != nil {
+				log.Fatalf("load %s: %s", &resourceState.CacheRecord, errors.ErrorStack(err))
+			}
+			if err = s.handleDelete(ctx, resourceState.Name, resource, repr); err != nil {
+				log.Fatalf("delete resource %s: %s", resourceState.Name, errors.ErrorStack(err))
+			}
+		}
+		// nothing is deleted in dry run
+		if config.DryRun {
+			continue
+		}
+		s.state.Resources = resourceStates[:i]
+		if err := s.state.Save(s.statePath); err != nil {
+			log.Fatal(errors.ErrorStack(err))
+		}
+	}
+
+	if e := s.cacheFile.Close(); e != nil {
+		log.Println(errors.Annotate(e, "close cache file"))
+	}
+	if config.DryRun {
+		return
+	}
+	if e := os.Remove(s.cacheFilePath); e != nil {
+		log.Println(errors.Annotate(e, "remove cache file"))
+	}
+	if e := os.Remove(s.statePath); e != nil && !os.IsNotExist(e) {
+		log.Println(errors.Annotate(e, "remove state file"))
+	}
+}
+
+func (s *Stack) record(key, resourceName, resourceType, action string, value interface{},
+	adoptedItems []string, properties map[string]interface{}) error {
+
+	// resources got in plan or dry run have fake values, which would overwrite the real ones
+	if resourceType == utils.ResourceToken || config.Plan || config.DryRun {
 		return nil
 	}
-	cacheRecord, err := GetCacheRecord(resourceName, resourceType, value, s.inTmpl)
-	if err != nil {
+	var cacheRecord *CacheRecord
+	var err error
+	if action == actionSkipped {
+		cacheRecord = &CacheRecord{Name: resourceName, ResourceType: resourceType,
+			InTemplate: s.tmplDepth > 0, Depth: s.tmplDepth, Value: json.RawMessage("null")}
+	} else if cacheRecord, err = GetCacheRecord(resourceName, resourceType, value, s.tmplDepth); err != nil {
 		return errors.Trace(err)
 	}
+	cacheRecord.Key, cacheRecord.Action, cacheRecord.AdoptedItems = key, action, adoptedItems
 	bytes, err := json.Marshal(cacheRecord)
 	if err != nil {
 		return errors.Trace(err)
@@ -440,16 +927,22 @@ func (s *Stack) record(resourceName, resourceType string, value interface{}) err
 	if err != nil {
 		return errors.Trace(err)
 	}
+
+	s.state.Put(s.stateIndex, cacheRecord, properties)
+	s.stateIndex++
+	if err = s.state.Save(s.statePath); err != nil {
+		return errors.Annotatef(err, "save state of resource %s", resourceName)
+	}
 	return nil
 }
 
-func (s *Stack) handleGet(
+func (s *Stack) handleGet(ctx context.Context,
 	name string, resource utils.ResourceInterface, waitInterval, checkInterval int) (err error) {
 
 	rType := resource.GetType()
 	log.Printf("try to update resource %s of type %s...", name, rType)
 
-	err = resource.Get()
+	err = resource.Get(ctx)
 	if err != nil {
 		return errors.Annotatef(err, "get resource %s of type %s", name, rType)
 	}
@@ -458,23 +951,25 @@ func (s *Stack) handleGet(
 	return nil
 }
 
-func (s *Stack) handleUpdate(
+func (s *Stack) handleUpdate(ctx context.Context,
 	name string, resource utils.ResourceInterface, waitInterval, checkInterval int) (err error) {
 
+	s.lock.RLock()
 	repr, ok := s.resourceValueMap[name]
+	s.lock.RUnlock()
 	if !ok {
 		return errors.Errorf("failed to get resource %s", name)
 	}
 	rType := resource.GetType()
 	log.Printf("try to update resource %s of type %s...", name, rType)
 
-	updated, err := resource.Update(repr)
+	updated, err := resource.Update(ctx, repr)
 	if err != nil {
 		return errors.Annotatef(err, "failed to update resource %s of type %s", name, rType)
 	}
 
-	if !updated {
-		if err = s.waitUpdated(name, resource, waitInterval, checkInterval); err != nil {
+	if !updated && !config.Plan {
+		if err = s.waitUpdated(ctx, name, resource, waitInterval, checkInterval); err != nil {
 			return errors.Trace(err)
 		}
 	}
@@ -484,17 +979,19 @@ func (s *Stack) handleUpdate(
 	return nil
 }
 
-func (s *Stack) waitUpdated(
+func (s *Stack) waitUpdated(ctx context.Context,
 	name string, resource utils.ResourceInterface, waitInterval, checkInterval int) (err error) {
 
 	if waitInterval > 0 {
-		time.Sleep(time.Duration(waitInterval) * time.Second)
+		if err = utils.Sleep(ctx, time.Duration(waitInterval)*time.Second); err != nil {
+			return errors.Trace(err)
+		}
 	}
 
 	log.Printf("start to check status of resource %s", name)
 	for i := 1; i <= utils.DefaultCheckCount; i++ {
 		log.Printf("check %d time(s).", i)
-		updated, err := resource.IsUpdated()
+		updated, err := resource.IsUpdated(ctx)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -502,17 +999,19 @@ func (s *Stack) waitUpdated(
 			return nil
 		}
 
-		if checkInterval > 0 {
-			time.Sleep(time.Duration(checkInterval) * time.Second)
-		} else {
-			time.Sleep(time.Duration(resource.CheckInterval()) * time.Second)
+		interval := checkInterval
+		if interval <= 0 {
+			interval = resource.CheckInterval()
+		}
+		if err = utils.Sleep(ctx, time.Duration(interval)*time.Second); err != nil {
+			return errors.Trace(err)
 		}
 	}
 
 	return errors.Errorf("timeout for waiting resource %s to be updated", name)
 }
 
-func (s *Stack) handleCreate(
+func (s *Stack) handleCreate(ctx context.Context,
 	name string, resource utils.ResourceInterface, waitInterval, checkInterval int) (err error) {
 
 	rType := resource.GetType()
@@ -521,12 +1020,12 @@ func (s *Stack) handleCreate(
 		return errors.Errorf("create resource %s without token", name)
 	}
 
-	created, err := resource.Create()
+	created, err := resource.Create(ctx)
 	if err != nil {
 		return errors.Annotatef(err, "failed to create resource %s of type %s", name, rType)
 	}
-	if !created {
-		if err = s.waitCreated(name, resource, waitInterval, checkInterval); err != nil {
+	if !created && !config.Plan {
+		if err = s.waitCreated(ctx, name, resource, waitInterval, checkInterval); err != nil {
 			return errors.Trace(err)
 		}
 	}
@@ -536,22 +1035,28 @@ func (s *Stack) handleCreate(
 	if rType == utils.ResourceToken {
 		s.token = resource.Repr().(string)
 		s.GetOpenAPIClient().SetToken(s.token)
-		log.Printf("reset %s to %s", utils.XmsHeaderAuthToken, s.token)
+		log.Printf("reset %s", utils.XmsHeaderAuthToken)
+		// token is created again with the same credentials once it expires
+		if token, ok := resource.(*resources.Token); ok {
+			s.GetOpenAPIClient().SetCredentialProvider(token.Reauthenticate)
+		}
 	}
 	return nil
 }
 
-func (s *Stack) waitCreated(
+func (s *Stack) waitCreated(ctx context.Context,
 	name string, resource utils.ResourceInterface, waitInterval, checkInterval int) (err error) {
 
 	if waitInterval > 0 {
-		time.Sleep(time.Duration(waitInterval) * time.Second)
+		if err = utils.Sleep(ctx, time.Duration(waitInterval)*time.Second); err != nil {
+			return errors.Trace(err)
+		}
 	}
 
 	log.Printf("start to check status of resource %s", name)
 	for i := 1; i <= utils.DefaultCheckCount; i++ {
 		log.Printf("check %d time(s).", i)
-		created, err := resource.IsCreated()
+		created, err := resource.IsCreated(ctx)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -559,28 +1064,125 @@ func (s *Stack) waitCreated(
 			return nil
 		}
 
-		if checkInterval > 0 {
-			time.Sleep(time.Duration(checkInterval) * time.Second)
-		} else {
-			time.Sleep(time.Duration(resource.CheckInterval()) * time.Second)
+		interval := checkInterval
+		if interval <= 0 {
+			interval = resource.CheckInterval()
+		}
+		if err = utils.Sleep(ctx, time.Duration(interval)*time.Second); err != nil {
+			return errors.Trace(err)
 		}
 	}
 
 	return errors.Errorf("timeout for waiting resource %s to be created", name)
 }
 
+func (s *Stack) handleDelete(ctx context.Context, name string, resource utils.ResourceInterface,
+	repr interface{}) (err error) {
+
+	rType := resource.GetType()
+	log.Printf("try to delete resource %s of type %s with representation %v...", name, rType, repr)
+
+	deleted, err := resource.Delete(ctx, repr)
+	if err != nil {
+		return errors.Annotatef(err, "failed to delete resource %s of type %s", name, rType)
+	}
+	if !deleted {
+		if err = s.waitDeleted(ctx, name, resource); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	log.Printf("resource %s with representation %v has been deleted successfully!!!", name, repr)
+	return nil
+}
+
+func (s *Stack) waitDeleted(ctx context.Context, name string, resource utils.ResourceInterface) (
+	err error) {
+
+	log.Printf("start to check status of resource %s", name)
+	for i := 1; i <= utils.DefaultCheckCount; i++ {
+		log.Printf("check %d time(s).", i)
+		deleted, err := resource.IsDeleted(ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if deleted {
+			return nil
+		}
+
+		if err = utils.Sleep(ctx, time.Duration(resource.CheckInterval())*time.Second); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	return errors.Errorf("timeout for waiting resource %s to be deleted", name)
+}
+
+func (s *Stack) evaluateConditions() error {
+	if err := s.template.checkConditions(s.template.Resources); err != nil {
+		return errors.Trace(err)
+	}
+	s.conditionValues = make(map[string]bool, len(s.template.Conditions))
+	s.evaluatingConditions = map[string]bool{}
+	for name := range s.template.Conditions {
+		if _, err := s.GetCondition(name); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// GetCondition returns value of condition with specific name, conditions are evaluated
+// on init so they can't reference resources
+func (s *Stack) GetCondition(name string) (bool, error) {
+	if value, ok := s.conditionValues[name]; ok {
+		return value, nil
+	}
+	expr, ok := s.template.Conditions[name]
+	if !ok || s.evaluatingConditions == nil {
+		return false, errors.NotFoundf("condition %s", name)
+	}
+	if s.evaluatingConditions[name] {
+		return false, errors.Errorf("condition %s references itself", name)
+	}
+	s.evaluatingConditions[name] = true
+	defer delete(s.evaluatingConditions, name)
+	value, err := expr.GetValue(s)
+	if err != nil {
+		return false, errors.Annotatef(err, "evaluate condition %s", name)
+	}
+	s.conditionValues[name] = value
+	return value, nil
+}
+
+// FindInMap returns value in mappings of template with the map name and keys
+func (s *Stack) FindInMap(mapName, topKey, secondKey string) (json.RawMessage, error) {
+	m, ok := s.template.Mappings[mapName]
+	if !ok {
+		return nil, errors.NotFoundf("mapping %s", mapName)
+	}
+	values, ok := m[topKey]
+	if !ok {
+		return nil, errors.NotFoundf("key %s in mapping %s", topKey, mapName)
+	}
+	value, ok := values[secondKey]
+	if !ok {
+		return nil, errors.NotFoundf("key %s.%s in mapping %s", topKey, secondKey, mapName)
+	}
+	return value, nil
+}
+
 // GetResourceValue returns resource value with specific name
 // value search order:
-//      1. template context
-//      2. resource value map
-//      3. value contexts
+//  1. template context
+//  2. resource value map
+//  3. value contexts, from the innermost template to the outermost one
 func (s *Stack) GetResourceValue(name string) interface{} {
 	// TODO: return as val, exist format
-	if s.templateContext != nil {
-		value := s.templateContext[name]
-		if value != nil {
-			return value
-		}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if value := s.templateContext[name]; value != nil {
+		return value
 	}
 	value := s.resourceValueMap[name]
 	if value != nil {
@@ -588,9 +1190,11 @@ func (s *Stack) GetResourceValue(name string) interface{} {
 	}
 	contextBack := s.valueContexts.Back()
 	for contextBack != nil {
-		values := contextBack.Value.(map[string]interface{})
-		value := values[name]
-		if value != nil {
+		frame := contextBack.Value.(*valueContext)
+		if value := frame.context[name]; value != nil {
+			return value
+		}
+		if value := frame.values[name]; value != nil {
 			return value
 		}
 		contextBack = contextBack.Prev()
@@ -598,17 +1202,33 @@ func (s *Stack) GetResourceValue(name string) interface{} {
 	return nil
 }
 
+// valueContext saves template context and resource values of an enclosing template, or
+// values of the stack if the template is not nested
+type valueContext struct {
+	context map[string]interface{}
+	values  map[string]interface{}
+}
+
+// pushContext saves current context and values, then enters a template with the context
 func (s *Stack) pushContext(context map[string]interface{}) {
-	s.valueContexts.PushBack(context)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.valueContexts.PushBack(&valueContext{context: s.templateContext, values: s.resourceValueMap})
+	s.templateContext = context
+	s.resourceValueMap = map[string]interface{}{}
 }
 
-func (s *Stack) popContext() map[string]interface{} {
+// popContext leaves current template and restores context and values of enclosing one
+func (s *Stack) popContext() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	last := s.valueContexts.Back()
 	if last == nil {
-		return nil
+		return
 	}
 	s.valueContexts.Remove(last)
-	return last.Value.(map[string]interface{})
+	frame := last.Value.(*valueContext)
+	s.templateContext, s.resourceValueMap = frame.context, frame.values
 }
 
 func (s *Stack) getTemplate(name string) json.RawMessage {
@@ -1,16 +1,32 @@
 package formation
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
+	"xsky.com/sds-formation/config"
+	openapiClient "xsky.com/sds-formation/openapi-client"
+	resources "xsky.com/sds-formation/resources"
 	"xsky.com/sds-formation/tests"
+	"xsky.com/sds-formation/utils"
 )
 
 type stackLoadCacheSuite struct {
@@ -44,7 +60,7 @@ func (s *stackLoadCacheSuite) TestWithNoCacheFile() {
 
 func (s *stackLoadCacheSuite) TestLoadCache() {
 	cacheData := `{"Name":"token","ResourceType":"Token","ValueType":"string","Value":"17412dde75c34e92ad7d931bb4b2c287"}
-{"Name":"disk_list","ResourceType":"DiskList","ValueType":"[]int64","Value":[1,2]}
+{"Key":"1","Name":"disk_list","ResourceType":"DiskList","ValueType":"[]int64","Value":[1,2]}
 `
 	s.mockedFile.SetReadData([]byte(cacheData))
 	s.mockedFile.On("Read", mock.AnythingOfType("[]uint8"))
@@ -60,6 +76,7 @@ func (s *stackLoadCacheSuite) TestLoadCache() {
 			Value:        json.RawMessage("\"17412dde75c34e92ad7d931bb4b2c287\""),
 		},
 		{
+			Key:          "1",
 			Name:         "disk_list",
 			ResourceType: "DiskList",
 			ValueType:    "[]int64",
@@ -67,6 +84,8 @@ func (s *stackLoadCacheSuite) TestLoadCache() {
 		},
 	}
 	assert.Equal(s.T(), expCacheRecords, s.stack.cacheExprs)
+	// record without key is ignored
+	assert.Equal(s.T(), map[string]*CacheRecord{"1": expCacheRecords[1]}, s.stack.cacheRecords)
 	assert.NotNil(s.T(), s.stack.cacheFile)
 }
 
@@ -285,3 +304,571 @@ func (s *makeTemplateResourceContextSuite) TestWithError() {
 func TestMakeTemplateResourceContextSuite(t *testing.T) {
 	suite.Run(t, new(makeTemplateResourceContextSuite))
 }
+
+type stackPlanSuite struct {
+	suite.Suite
+
+	stack *Stack
+}
+
+func (s *stackPlanSuite) SetupTest() {
+	config.Plan = true
+	s.stack = new(Stack)
+	s.stack.template = &Template{Parameters: map[string]*Parameter{}}
+}
+
+func (s *stackPlanSuite) TearDownTest() {
+	config.Plan = false
+}
+
+func (s *stackPlanSuite) newResource(name, typeName, action string) *ResourceInTemplate {
+	return &ResourceInTemplate{
+		Name:       name,
+		Type:       typeName,
+		Action:     action,
+		Properties: resources.NewResource(typeName, action),
+	}
+}
+
+func (s *stackPlanSuite) TestPlanResource() {
+	s.stack.planResource(s.newResource("pool", utils.ResourcePool, ""), new(resourceResult))
+	s.stack.planResource(s.newResource("disks", utils.ResourceDiskList, ""), new(resourceResult))
+	s.stack.planResource(s.newResource("host", utils.ResourceHost, utils.ActionTypeGet), new(resourceResult))
+	s.stack.planResource(s.newResource("volume", utils.ResourceBlockVolume, utils.ActionTypeUpdate), new(resourceResult))
+	s.stack.planResource(s.newResource("user", utils.ResourceUser, ""), &resourceResult{restored: true})
+	s.stack.planResource(s.newResource("group", utils.ResourceHost, ""), &resourceResult{skipped: true})
+
+	actions := make([]string, 0, len(s.stack.planEntries))
+	for _, entry := range s.stack.planEntries {
+		actions = append(actions, entry.Action)
+	}
+	s.Equal([]string{planActionAdopt, planActionRead, planActionRead, planActionNoChange, planActionCached,
+		planActionSkip},
+		actions)
+}
+
+func (s *stackPlanSuite) TestKnownAfterApply() {
+	var pool, volume, host ResourceInTemplate
+	s.NoError(json.Unmarshal([]byte(`{"Name": "pool", "Type": "Pool", "Properties": {"Name": "p1"}}`),
+		&pool))
+	s.NoError(json.Unmarshal([]byte(`{"Name": "volume", "Type": "BlockVolume", "Action": "Get",
+		"Properties": {"Name": "v1", "PoolID": {"Ref": "pool"}}}`), &volume))
+	s.NoError(json.Unmarshal([]byte(`{"Name": "host", "Type": "Host", "Action": "Get",
+		"Properties": {"AdminIP": "10.0.0.1"}}`), &host))
+	for _, r := range []*ResourceInTemplate{&pool, &volume, &host} {
+		r.Properties.Init(s.stack)
+	}
+	_, err := pool.Properties.(*resources.Pool).CallResourceAPI(context.Background(),
+		utils.CreateAPIName, nil, nil)
+	s.NoError(err)
+
+	s.stack.planResource(&pool, new(resourceResult))
+	s.stack.planResource(&volume, new(resourceResult))
+	s.stack.planResource(&host, new(resourceResult))
+	s.False(s.stack.planEntries[0].KnownAfterApply)
+	s.True(s.stack.planEntries[1].KnownAfterApply)
+	s.False(s.stack.planEntries[2].KnownAfterApply)
+
+	// resources in template reference resources out of it
+	s.stack.templatePath = "volumes[0]"
+	s.stack.planResource(&volume, new(resourceResult))
+	s.True(s.stack.planEntries[3].KnownAfterApply)
+	s.stack.templatePath = ""
+	s.stack.planEntries[0].Template = "pools[0]"
+	s.stack.planResource(&volume, new(resourceResult))
+	s.False(s.stack.planEntries[4].KnownAfterApply)
+
+	var out bytes.Buffer
+	s.NoError(s.stack.printPlan(&out))
+	s.Contains(out.String(), "read      volume (BlockVolume) known after apply\n")
+	s.Contains(out.String(), "read      host (Host)\n")
+}
+
+func (s *stackPlanSuite) TestPrintPlan() {
+	s.stack.planEntries = []*planEntry{{
+		Name:     "pool",
+		Type:     utils.ResourcePool,
+		Template: "poolTemplate[0]",
+		Action:   planActionCreate,
+		Requests: []*utils.PlannedRequest{{API: "CreatePool", Body: []byte(`{"pool":{"name":"p1"}}`)}},
+	}}
+	var out bytes.Buffer
+	s.NoError(s.stack.printPlan(&out))
+	s.Contains(out.String(), "create    poolTemplate[0].pool (Pool)")
+	s.Contains(out.String(), `"name": "p1"`)
+	s.Contains(out.String(), "Plan: 1 to create")
+}
+
+func TestStackPlanSuite(t *testing.T) {
+	suite.Run(t, new(stackPlanSuite))
+}
+
+type stackConditionSuite struct {
+	suite.Suite
+
+	stack *Stack
+}
+
+func (s *stackConditionSuite) SetupTest() {
+	s.stack = new(Stack)
+	s.stack.resourceValueMap = map[string]interface{}{}
+	s.stack.conditionValues = map[string]bool{"WithSSD": false}
+}
+
+func (s *stackConditionSuite) TestSkipResource() {
+	r := &ResourceInTemplate{
+		Name:       "pool",
+		Type:       utils.ResourcePool,
+		Condition:  "WithSSD",
+		Properties: resources.NewResource(utils.ResourcePool, ""),
+	}
+	result := s.stack.createResource(context.Background(), 0, r)
+	s.NoError(result.err)
+	s.True(result.skipped)
+	s.Equal(actionSkipped, result.action)
+	s.Nil(s.stack.GetResourceValue("pool"))
+
+	// skipped resource is restored from cache
+	s.stack.cacheRecords = map[string]*CacheRecord{
+		"0": {Key: "0", Name: "pool", ResourceType: utils.ResourcePool, Action: actionSkipped},
+	}
+	result = s.stack.createResource(context.Background(), 0, r)
+	s.NoError(result.err)
+	s.True(result.restored)
+
+	// condition changes since last run
+	s.stack.cacheRecords["0"].Action = utils.ActionTypeCreate
+	result = s.stack.createResource(context.Background(), 0, r)
+	s.Error(result.err)
+}
+
+func (s *stackConditionSuite) TestStopped() {
+	r := &ResourceInTemplate{
+		Name:       "pool",
+		Type:       utils.ResourcePool,
+		Properties: resources.NewResource(utils.ResourcePool, ""),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := s.stack.createResource(ctx, 0, r)
+	s.EqualError(result.err, "resource pool is not handled: context canceled")
+
+	s.stack.Stop()
+	result = s.stack.createResource(context.Background(), 0, r)
+	s.EqualError(result.err, "resource pool is not handled: stack is stopped")
+}
+
+func TestStackConditionSuite(t *testing.T) {
+	suite.Run(t, new(stackConditionSuite))
+}
+
+type stackNestedTemplateSuite struct {
+	suite.Suite
+
+	stack *Stack
+}
+
+func (s *stackNestedTemplateSuite) SetupTest() {
+	config.Plan = true
+	s.stack = new(Stack)
+	s.stack.token = "28171a13317c4252806979bc86a69c4f"
+	s.stack.resourceValueMap = map[string]interface{}{"prefix": "vol"}
+	s.stack.template = new(Template)
+}
+
+func (s *stackNestedTemplateSuite) TearDownTest() {
+	config.Plan = false
+}
+
+func (s *stackNestedTemplateSuite) TestCreateNestedTemplate() {
+	s.NoError(json.Unmarshal([]byte(`{
+		"Templates": {
+			"rack": [{
+				"Name": "hosts",
+				"Type": "Template",
+				"TemplateName": "host",
+				"Context": [{"Name": "host", "Type": "StringList", "Value": ["h1", "h2"], "Action": "range"}]
+			}],
+			"host": [{
+				"Name": "names",
+				"Type": "StringList",
+				"Properties": {"Attributes": [{"Sub": "${prefix}-${rack}-${host}"}]}
+			}]
+		},
+		"Resources": [{
+			"Name": "racks",
+			"Type": "Template",
+			"TemplateName": "rack",
+			"Context": [{"Name": "rack", "Type": "StringList", "Value": ["r1", "r2"], "Action": "range"}]
+		}]
+	}`), s.stack.template))
+	s.NoError(s.stack.template.CheckTemplates())
+
+	s.NoError(s.stack.CreateResources(context.Background(), s.stack.template.Resources))
+	hosts := func(rack string) map[string]interface{} {
+		return map[string]interface{}{"hosts": []map[string]interface{}{
+			{"names": []string{"vol-" + rack + "-h1"}},
+			{"names": []string{"vol-" + rack + "-h2"}},
+		}}
+	}
+	s.Equal([]map[string]interface{}{hosts("r1"), hosts("r2")}, s.stack.GetResourceValue("racks"))
+	s.Nil(s.stack.GetResourceValue("rack"))
+	s.Equal(0, s.stack.valueContexts.Len())
+
+	templates := make([]string, 0, len(s.stack.planEntries))
+	for _, entry := range s.stack.planEntries {
+		templates = append(templates, entry.Template)
+	}
+	s.Equal([]string{"racks[0].hosts[0]", "racks[0].hosts[1]", "racks[1].hosts[0]", "racks[1].hosts[1]"},
+		templates)
+}
+
+func (s *stackNestedTemplateSuite) TestCheckRecursiveTemplate() {
+	s.NoError(json.Unmarshal([]byte(`{
+		"Templates": {
+			"rack": [{"Name": "hosts", "Type": "Template", "TemplateName": "host"}],
+			"host": [{"Name": "racks", "Type": "Template", "TemplateName": "rack"}]
+		}
+	}`), s.stack.template))
+	s.Error(s.stack.template.CheckTemplates())
+
+	s.NoError(json.Unmarshal([]byte(`{
+		"Templates": {"rack": [{"Name": "hosts", "Type": "Template", "TemplateName": "host"}]}
+	}`), s.stack.template))
+	s.Error(s.stack.template.CheckTemplates())
+}
+
+func TestStackNestedTemplateSuite(t *testing.T) {
+	suite.Run(t, new(stackNestedTemplateSuite))
+}
+
+const poolsSpec = `{
+	"openapi": "3.0.0",
+	"info": {"version": "SDS_4.2.009.0"},
+	"paths": {
+		"/auth/tokens": {"post": {"operationId": "CreateToken"}},
+		"/block-volumes/": {"get": {"operationId": "ListBlockVolumes"},
+			"post": {"operationId": "CreateBlockVolume"}},
+		"/block-volumes/{block_volume_id}": {
+			"get": {"operationId": "GetBlockVolume", "parameters": [{"name": "block_volume_id", "in": "path"}]},
+			"delete": {"operationId": "DeleteBlockVolume",
+				"parameters": [{"name": "block_volume_id", "in": "path"}]}
+		},
+		"/pools/": {"get": {"operationId": "ListPools"}, "post": {"operationId": "CreatePool"}},
+		"/pools/{pool_id}": {
+			"get": {"operationId": "GetPool", "parameters": [{"name": "pool_id", "in": "path"}]},
+			"delete": {"operationId": "DeletePool", "parameters": [{"name": "pool_id", "in": "path"}]}
+		}
+	}
+}`
+
+type stackDestroySuite struct {
+	suite.Suite
+
+	dir         string
+	oldOpenFile OpenFileFunc
+	server      *httptest.Server
+	stack       *Stack
+	deleted     []string
+	// pools existing on server
+	pools map[string]string
+	// block volumes existing on server
+	volumes map[string]string
+	// onCreate is called with name of pool being created if it's set
+	onCreate func(name string)
+	lock     sync.Mutex
+}
+
+func (s *stackDestroySuite) SetupTest() {
+	s.oldOpenFile = OpenFile
+	OpenFile = realOpenFile
+	dir, err := ioutil.TempDir("", "formation-destroy")
+	s.NoError(err)
+	s.dir = dir
+	s.deleted = nil
+	s.pools = map[string]string{"1": "pool1", "2": "pool2"}
+	s.volumes = map[string]string{}
+	s.onCreate = nil
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	specFile := filepath.Join(dir, "openapi.json")
+	s.NoError(ioutil.WriteFile(specFile, []byte(poolsSpec), 0644))
+	client := openapiClient.NewOpenAPIClient(nil)
+	client.SetServer(s.server.URL)
+	s.NoError(client.Init())
+	client.SetSpecFile(specFile)
+	s.NoError(client.LoadSpec(context.Background()))
+
+	s.stack = new(Stack)
+	s.stack.token = "28171a13317c4252806979bc86a69c4f"
+	s.stack.openapiClient = client
+	s.stack.resourceValueMap = map[string]interface{}{}
+	s.stack.template = new(Template)
+	s.stack.state = NewState("test", s.server.URL)
+	s.stack.statePath = filepath.Join(dir, "test.state.json")
+	s.stack.cacheFilePath = filepath.Join(dir, "test.cache")
+	s.stack.cacheFile, err = os.Create(s.stack.cacheFilePath)
+	s.NoError(err)
+}
+
+func (s *stackDestroySuite) TearDownTest() {
+	OpenFile = s.oldOpenFile
+	s.server.Close()
+	os.RemoveAll(s.dir)
+}
+
+func (s *stackDestroySuite) handle(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/auth/tokens" {
+		io.WriteString(w, `{"token": {"uuid": "9a4e8cd1ffb84b7c9a0c4b8c1b4f2e6d"}}`)
+		return
+	}
+	if strings.HasPrefix(req.URL.Path, "/block-volumes/") {
+		s.handleVolume(w, req)
+		return
+	}
+	id := strings.TrimPrefix(req.URL.Path, "/pools/")
+	if req.Method == http.MethodPost {
+		body := new(resources.PoolCreateReq)
+		s.NoError(json.NewDecoder(req.Body).Decode(body))
+		if s.onCreate != nil {
+			s.onCreate(body.Pool.Name)
+		}
+		s.lock.Lock()
+		id = strconv.Itoa(len(s.pools) + 1)
+		s.pools[id] = body.Pool.Name
+		s.lock.Unlock()
+		fmt.Fprintf(w, `{"pool": {"id": %s, "status": "active"}}`, id)
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch {
+	case req.Method == http.MethodGet && id == "":
+		pools := []map[string]interface{}{}
+		for poolID, name := range s.pools {
+			pools = append(pools, map[string]interface{}{"id": json.Number(poolID), "name": name})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"pools": pools})
+	case s.pools[id] == "":
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "{}")
+	case req.Method == http.MethodGet:
+		fmt.Fprintf(w, `{"pool": {"id": %s, "status": "active"}}`, id)
+	case req.Method == http.MethodDelete:
+		s.deleted = append(s.deleted, id)
+		delete(s.pools, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *stackDestroySuite) handleVolume(w http.ResponseWriter, req *http.Request) {
+	id := strings.TrimPrefix(req.URL.Path, "/block-volumes/")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch {
+	case req.Method == http.MethodPost:
+		body := new(resources.VolumeCreateReq)
+		s.NoError(json.NewDecoder(req.Body).Decode(body))
+		id = strconv.Itoa(len(s.volumes) + 11)
+		s.volumes[id] = body.Volume.Name
+		fmt.Fprintf(w, `{"block_volume": {"id": %s, "status": "active"}}`, id)
+	case req.Method == http.MethodGet && id == "":
+		volumes := []map[string]interface{}{}
+		for volumeID, name := range s.volumes {
+			volumes = append(volumes, map[string]interface{}{"id": json.Number(volumeID), "name": name})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"block_volumes": volumes})
+	case s.volumes[id] == "":
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "{}")
+	case req.Method == http.MethodGet:
+		fmt.Fprintf(w, `{"block_volume": {"id": %s, "status": "active"}}`, id)
+	case req.Method == http.MethodDelete:
+		s.deleted = append(s.deleted, id)
+		delete(s.volumes, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *stackDestroySuite) putState(name, action string, id int64) {
+	record, err := GetCacheRecord(name, utils.ResourcePool, id, 0)
+	s.NoError(err)
+	record.Action = action
+	s.stack.state.Put(len(s.stack.state.Resources), record, nil)
+}
+
+func (s *stackDestroySuite) TestAdoptExistingResource() {
+	s.NoError(json.Unmarshal([]byte(`{
+		"Resources": [{"Name": "pool", "Type": "Pool", "Properties": {"Name": "pool2"}}]
+	}`), s.stack.template))
+	r := s.stack.template.Resources[0]
+	r.Properties.Init(s.stack)
+
+	result := s.stack.createResource(context.Background(), 0, r)
+	s.NoError(result.err)
+	s.Equal(actionAdopted, result.action)
+	s.Equal(int64(2), s.stack.GetResourceValue("pool"))
+
+	// resource created by the stack is still recorded as created when it's found again
+	s.putState("pool", utils.ActionTypeCreate, 2)
+	s.NoError(s.stack.record("0", "pool", result.rType, result.action, result.repr, nil, nil))
+	s.Equal(utils.ActionTypeCreate, s.stack.state.Resources[0].Action)
+}
+
+func (s *stackDestroySuite) TestDestroyPartlyAdoptedList() {
+	s.volumes["11"] = "vol-1"
+	s.NoError(json.Unmarshal([]byte(`{
+		"Resources": [{"Name": "volumes", "Type": "BlockVolumes",
+			"Properties": {"Prefix": "vol", "Num": 2, "PoolID": 1, "Size": 1024}}]
+	}`), s.stack.template))
+	r := s.stack.template.Resources[0]
+	r.Properties.Init(s.stack)
+
+	result := s.stack.createResource(context.Background(), 0, r)
+	s.NoError(result.err)
+	s.Equal([]int64{11, 12}, result.repr)
+	s.stack.commitResource(r, result)
+	s.Equal(utils.ActionTypeCreate, s.stack.state.Resources[0].Action)
+	s.Equal([]string{"11"}, s.stack.state.Resources[0].AdoptedItems)
+
+	// the list is found again if the cache is lost, created items are still deleted
+	result = s.stack.createResource(context.Background(), 0, r)
+	s.NoError(result.err)
+	s.Equal(actionAdopted, result.action)
+	s.stack.stateIndex = 0
+	s.stack.commitResource(r, result)
+	s.Len(s.stack.state.Resources, 1)
+
+	s.stack.Destroy(context.Background())
+
+	s.Equal([]string{"12"}, s.deleted)
+	s.Equal(map[string]string{"11": "vol-1"}, s.volumes)
+}
+
+func (s *stackDestroySuite) TestDestroy() {
+	s.putState("pool1", utils.ActionTypeCreate, 1)
+	s.putState("pool2", actionAdopted, 2)
+	// pool3 has been deleted out of the stack
+	s.putState("pool3", utils.ActionTypeCreate, 3)
+	s.NoError(s.stack.state.Save(s.stack.statePath))
+
+	s.stack.Destroy(context.Background())
+
+	s.Equal([]string{"1"}, s.deleted)
+	s.Equal(map[string]string{"2": "pool2"}, s.pools)
+	s.Empty(s.stack.state.Resources)
+	_, err := os.Stat(s.stack.statePath)
+	s.True(os.IsNotExist(err))
+	_, err = os.Stat(s.stack.cacheFilePath)
+	s.True(os.IsNotExist(err))
+}
+
+func (s *stackDestroySuite) TestDestroyInReverseOrder() {
+	s.pools["3"] = "pool3"
+	s.putState("pool1", utils.ActionTypeCreate, 1)
+	s.putState("pool3", utils.ActionTypeCreate, 3)
+
+	s.stack.Destroy(context.Background())
+
+	s.Equal([]string{"3", "1"}, s.deleted)
+}
+
+func (s *stackDestroySuite) TestRedactTokenPassword() {
+	s.NoError(json.Unmarshal([]byte(`{
+		"Resources": [{"Name": "token", "Type": "Token",
+			"Properties": {"Name": "admin", "Password": "destroy-p@ssword"}}]
+	}`), s.stack.template))
+	s.stack.template.Resources[0].Properties.Init(s.stack)
+	s.putState("pool1", utils.ActionTypeCreate, 1)
+
+	s.stack.Destroy(context.Background())
+
+	s.Equal([]string{"1"}, s.deleted)
+	s.Equal("password ******", utils.Redact("password destroy-p@ssword"))
+}
+
+func (s *stackDestroySuite) TestDryRun() {
+	config.DryRun = true
+	defer func() { config.DryRun = false }()
+	s.putState("pool1", utils.ActionTypeCreate, 1)
+	s.NoError(s.stack.state.Save(s.stack.statePath))
+
+	s.NoError(s.stack.record("1", "pool2", utils.ResourcePool, utils.ActionTypeCreate, int64(10), nil, nil))
+	s.stack.Destroy(context.Background())
+
+	s.Empty(s.deleted)
+	state, err := LoadState(s.stack.statePath)
+	s.NoError(err)
+	s.Len(state.Resources, 1)
+	s.Equal("pool1", state.Resources[0].Name)
+	_, err = os.Stat(s.stack.cacheFilePath)
+	s.NoError(err)
+}
+
+func (s *stackDestroySuite) TestRecordAfterStop() {
+	s.NoError(json.Unmarshal([]byte(`{
+		"Resources": [
+			{"Name": "pool3", "Type": "Pool", "DependsOn": ["pool4"], "Properties": {"Name": "pool3"}},
+			{"Name": "pool4", "Type": "Pool", "Properties": {"Name": "pool4"}},
+			{"Name": "pool5", "Type": "Pool", "Properties": {"Name": "pool5"}}
+		]
+	}`), s.stack.template))
+	for _, r := range s.stack.template.Resources {
+		r.Properties.Init(s.stack)
+	}
+	workers := config.Workers
+	config.Workers = 2
+	defer func() { config.Workers = workers }()
+	pool5Started := make(chan struct{})
+	s.onCreate = func(name string) {
+		switch name {
+		case "pool4":
+			// stack is stopped while pool4 and pool5 are in progress
+			select {
+			case <-pool5Started:
+			case <-time.After(time.Second):
+				s.Fail("pool5 is not started")
+			}
+			s.stack.Stop()
+		case "pool5":
+			close(pool5Started)
+		}
+	}
+
+	err := s.stack.CreateResources(context.Background(), s.stack.template.Resources)
+	s.EqualError(err, "resource pool3 is not handled: stack is stopped")
+
+	// resources done after pool3 are recorded in state and cache
+	names := []string{}
+	for _, resourceState := range s.stack.state.Resources {
+		names = append(names, resourceState.Name)
+	}
+	s.Equal([]string{"pool4", "pool5"}, names)
+	cacheData, err := ioutil.ReadFile(s.stack.cacheFilePath)
+	s.NoError(err)
+	cacheRecords, err := readCacheRecords(cacheData)
+	s.NoError(err)
+	s.Len(cacheRecords, 2)
+
+	// they are restored by keys when the stack is continued
+	s.stack.stopped = 0
+	s.stack.state.Resources = nil
+	s.stack.stateIndex = 0
+	s.stack.cacheRecords = map[string]*CacheRecord{}
+	for _, cacheRecord := range cacheRecords {
+		s.stack.cacheRecords[cacheRecord.Key] = cacheRecord
+	}
+	s.onCreate = nil
+	s.NoError(s.stack.CreateResources(context.Background(), s.stack.template.Resources))
+	// each pool is created only once
+	poolNames := []string{}
+	for _, name := range s.pools {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+	s.Equal([]string{"pool1", "pool2", "pool3", "pool4", "pool5"}, poolNames)
+}
+
+func TestStackDestroySuite(t *testing.T) {
+	suite.Run(t, new(stackDestroySuite))
+}
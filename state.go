@@ -0,0 +1,134 @@
+package formation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/utils"
+)
+
+// StateVersion is version of the stack state document written by this formation
+const StateVersion = 1
+
+// State defines the persistent state of a stack, it records every resource the stack
+// has got, created or updated and is kept after the stack is created
+type State struct {
+	Version     int
+	Description string
+	ClusterURL  string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Resources   []*ResourceState
+}
+
+// ResourceState defines state of a resource in the stack
+type ResourceState struct {
+	CacheRecord
+	Properties map[string]interface{} `json:",omitempty"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// NewState returns an empty state of a stack
+func NewState(description, clusterURL string) *State {
+	now := time.Now()
+	return &State{
+		Version:     StateVersion,
+		Description: description,
+		ClusterURL:  clusterURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// LoadState loads state from the state file, returns nil if the file doesn't exist
+func LoadState(path string) (*State, error) {
+	exist, err := utils.FileExists(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !exist {
+		return nil, nil
+	}
+	stateFile, err := OpenFile(path, os.O_RDONLY)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer stateFile.Close()
+	stateData, err := ioutil.ReadAll(stateFile)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	state := new(State)
+	if err = json.Unmarshal(stateData, state); err != nil {
+		return nil, errors.Annotatef(err, "parse state file %s", path)
+	}
+	if state.Version > StateVersion {
+		return nil, errors.Errorf("unsupported version %d of state file %s", state.Version, path)
+	}
+	return state, nil
+}
+
+// Save writes the state to the file, the file is replaced as a whole so that it
+// is always complete
+func (st *State) Save(path string) error {
+	stateData, err := json.MarshalIndent(st, "", "    ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tmpPath := path + ".tmp"
+	tmpFile, err := OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err = tmpFile.Write(append(stateData, '\n')); err != nil {
+		tmpFile.Close()
+		return errors.Trace(err)
+	}
+	if err = tmpFile.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// Put sets state of resource at index, state of the same resource is updated and moved to
+// the index. States of other resources are kept after it, since resources recorded by former
+// runs could be renamed or removed from the template but they still need to be destroyed.
+func (st *State) Put(index int, record *CacheRecord, properties map[string]interface{}) {
+	now := time.Now()
+	st.UpdatedAt = now
+	resourceState := &ResourceState{
+		CacheRecord: *record,
+		Properties:  properties,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if index > len(st.Resources) {
+		index = len(st.Resources)
+	}
+	for i := index; i < len(st.Resources); i++ {
+		old := st.Resources[i]
+		if old.Name != record.Name || old.ResourceType != record.ResourceType {
+			continue
+		}
+		resourceState.CreatedAt = old.CreatedAt
+		// resource created by the stack before is found again if the cache is lost
+		if old.Action == utils.ActionTypeCreate && record.Action == actionAdopted &&
+			bytes.Equal(old.Value, record.Value) {
+			resourceState.Action, resourceState.AdoptedItems = old.Action, old.AdoptedItems
+		}
+		st.Resources = append(st.Resources[:i], st.Resources[i+1:]...)
+		break
+	}
+	st.Resources = append(st.Resources, nil)
+	copy(st.Resources[index+1:], st.Resources[index:])
+	st.Resources[index] = resourceState
+}
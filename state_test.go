@@ -0,0 +1,101 @@
+package formation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type stateSuite struct {
+	suite.Suite
+
+	dir         string
+	oldOpenFile OpenFileFunc
+}
+
+func (s *stateSuite) SetupTest() {
+	s.oldOpenFile = OpenFile
+	OpenFile = realOpenFile
+	dir, err := ioutil.TempDir("", "formation-state")
+	s.NoError(err)
+	s.dir = dir
+}
+
+func (s *stateSuite) TearDownTest() {
+	OpenFile = s.oldOpenFile
+	os.RemoveAll(s.dir)
+}
+
+func (s *stateSuite) newRecord(name string, value int64) *CacheRecord {
+	record, err := GetCacheRecord(name, "Pool", value, 0)
+	s.NoError(err)
+	return record
+}
+
+func (s *stateSuite) TestPut() {
+	state := NewState("test", "http://10.0.0.1:8056/v1")
+	state.Put(0, s.newRecord("pool1", 1), map[string]interface{}{"Name": "pool1"})
+	state.Put(1, s.newRecord("pool2", 2), nil)
+	createdAt := state.Resources[0].CreatedAt
+
+	// same resource at the index is updated
+	state.Put(0, s.newRecord("pool1", 3), nil)
+	s.Len(state.Resources, 2)
+	s.Equal(createdAt, state.Resources[0].CreatedAt)
+	s.Equal(json.RawMessage("3"), state.Resources[0].Value)
+
+	// different resource is inserted at the index and following states are kept
+	state.Put(1, s.newRecord("pool3", 4), nil)
+	state.Put(2, s.newRecord("pool4", 5), nil)
+	s.Equal([]string{"pool1", "pool3", "pool4", "pool2"}, s.names(state))
+
+	// same resource after the index is moved to the index
+	state.Put(1, s.newRecord("pool2", 6), nil)
+	s.Equal([]string{"pool1", "pool2", "pool3", "pool4"}, s.names(state))
+	s.Equal(json.RawMessage("6"), state.Resources[1].Value)
+}
+
+func (s *stateSuite) names(state *State) []string {
+	names := make([]string, 0, len(state.Resources))
+	for _, resourceState := range state.Resources {
+		names = append(names, resourceState.Name)
+	}
+	return names
+}
+
+func (s *stateSuite) TestSaveAndLoad() {
+	path := filepath.Join(s.dir, "test.state.json")
+	state, err := LoadState(path)
+	s.NoError(err)
+	s.Nil(state)
+
+	state = NewState("test", "http://10.0.0.1:8056/v1")
+	state.Put(0, s.newRecord("pool1", 1), map[string]interface{}{"Name": "pool1"})
+	s.NoError(state.Save(path))
+
+	loaded, err := LoadState(path)
+	s.NoError(err)
+	s.Equal(StateVersion, loaded.Version)
+	s.Equal("http://10.0.0.1:8056/v1", loaded.ClusterURL)
+	s.Len(loaded.Resources, 1)
+	s.Equal(map[string]interface{}{"Name": "pool1"}, loaded.Resources[0].Properties)
+	repr, err := loaded.Resources[0].GetExpr()
+	s.NoError(err)
+	s.Equal(int64(1), repr)
+}
+
+func (s *stateSuite) TestLoadNewerVersion() {
+	path := filepath.Join(s.dir, "test.state.json")
+	s.NoError(ioutil.WriteFile(path, []byte(`{"Version": 100}`), 0644))
+
+	_, err := LoadState(path)
+	s.Error(err)
+}
+
+func TestStateSuite(t *testing.T) {
+	suite.Run(t, new(stateSuite))
+}
@@ -2,6 +2,7 @@ package formation
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/juju/errors"
 
@@ -10,28 +11,93 @@ import (
 	"xsky.com/sds-formation/utils"
 )
 
-var inTemplate = false
-
 // Template resource template
 type Template struct {
-	Description string                     `json:",omitempty"`
-	Parameters  map[string]*Parameter      `json:",omitempty"`
-	Resources   []*ResourceInTemplate      `json:",omitempty"`
-	Templates   map[string]json.RawMessage `json:",omitempty"`
+	Description string                      `json:",omitempty"`
+	Parameters  map[string]*Parameter       `json:",omitempty"`
+	Conditions  map[string]*parser.BoolExpr `json:",omitempty"`
+	Mappings    map[string]mapping          `json:",omitempty"`
+	Resources   []*ResourceInTemplate       `json:",omitempty"`
+	Templates   map[string]json.RawMessage  `json:",omitempty"`
+	Imports     map[string]string           `json:",omitempty"`
+	Outputs     map[string]*Output          `json:",omitempty"`
+
+	// modules loaded from Imports, they are also added to Templates
+	modules map[string]*module
 }
 
-// CheckTemplates check resources templates is valid
+// mapping defines values keyed by top level key and second level key, e.g. values of sites
+// keyed by datacenter name
+type mapping map[string]map[string]json.RawMessage
+
+// CheckTemplates check resources templates is valid, templates could instantiate other
+// templates but can't instantiate themselves directly or indirectly
 func (t *Template) CheckTemplates() error {
-	// nested tempalte not support currently, this check may removed in future
-	inTemplate = true
-	defer func() {
-		inTemplate = false
-	}()
+	templateResources := make(map[string][]*ResourceInTemplate, len(t.Templates))
 	for templateName, templateData := range t.Templates {
 		tmpResurces := make([]*ResourceInTemplate, 0)
 		if err := json.Unmarshal(templateData, &tmpResurces); err != nil {
 			return errors.Annotatef(err, "in template %s", templateName)
 		}
+		if _, err := buildResourceGraph(tmpResurces); err != nil {
+			return errors.Annotatef(err, "in template %s", templateName)
+		}
+		if err := t.checkConditions(tmpResurces); err != nil {
+			return errors.Annotatef(err, "in template %s", templateName)
+		}
+		templateResources[templateName] = tmpResurces
+	}
+
+	// names of templates being checked, in order of instantiation
+	var path []string
+	checked := map[string]bool{}
+	var checkInstantiation func([]*ResourceInTemplate) error
+	checkInstantiation = func(resources []*ResourceInTemplate) error {
+		for _, r := range resources {
+			if r.Type != utils.ResourceTemplate || checked[r.TemplateName] {
+				continue
+			}
+			tmpResurces, ok := templateResources[r.TemplateName]
+			if !ok {
+				return errors.NotFoundf("template %s of resource %s", r.TemplateName, r.Name)
+			}
+			for i, name := range path {
+				if name == r.TemplateName {
+					return errors.Errorf("template instantiates itself: %s",
+						strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+			path = append(path, r.TemplateName)
+			if err := checkInstantiation(tmpResurces); err != nil {
+				return errors.Trace(err)
+			}
+			path = path[:len(path)-1]
+			checked[r.TemplateName] = true
+		}
+		return nil
+	}
+	if err := checkInstantiation(t.Resources); err != nil {
+		return errors.Trace(err)
+	}
+	for templateName, tmpResurces := range templateResources {
+		path = []string{templateName}
+		if err := checkInstantiation(tmpResurces); err != nil {
+			return errors.Annotatef(err, "in template %s", templateName)
+		}
+		checked[templateName] = true
+	}
+	return nil
+}
+
+// checkConditions checks conditions of resources are defined in Conditions
+func (t *Template) checkConditions(resources []*ResourceInTemplate) error {
+	for _, r := range resources {
+		if r.Condition == "" {
+			continue
+		}
+		if _, ok := t.Conditions[r.Condition]; !ok {
+			return errors.NotFoundf("condition %s of resource %s", r.Condition, r.Name)
+		}
 	}
 	return nil
 }
@@ -44,6 +110,8 @@ type ResourceInTemplate struct {
 	Sleep         int
 	WaitInterval  int
 	CheckInterval int
+	DependsOn     []string
+	Condition     string
 	Context       []*templateContext
 	TemplateName  string
 	Properties    utils.ResourceInterface
@@ -104,6 +172,8 @@ func (t *templateContext) UnmarshalJSON(buf []byte) (err error) {
 		t.Value = new(parser.StringExpr)
 	case utils.ContextTypeBool:
 		t.Value = new(parser.BoolExpr)
+	case utils.ContextTypeBoolList:
+		t.Value = new(parser.BoolListExpr)
 	default:
 		return errors.Errorf("got invalid template context type %s", t.Type)
 	}
@@ -137,10 +207,6 @@ func (r *ResourceInTemplate) UnmarshalJSON(buf []byte) (err error) {
 		return errors.Trace(err)
 	}
 
-	if inTemplate && r.Type == utils.ResourceTemplate {
-		return errors.New("nested template unsupported")
-	}
-
 	templateNameBytes, ok := m["TemplateName"]
 	if !ok && r.Type == utils.ResourceTemplate {
 		return errors.Errorf("TemplateName is required for tempalte resource")
@@ -185,6 +251,20 @@ func (r *ResourceInTemplate) UnmarshalJSON(buf []byte) (err error) {
 		}
 	}
 
+	conditionBytes, ok := m["Condition"]
+	if ok {
+		if err = json.Unmarshal(conditionBytes, &r.Condition); err != nil {
+			return errors.Annotatef(err, "parse Condition of resource %s", r.Name)
+		}
+	}
+
+	dependsOnBytes, ok := m["DependsOn"]
+	if ok {
+		if err = json.Unmarshal(dependsOnBytes, &r.DependsOn); err != nil {
+			return errors.Annotatef(err, "parse DependsOn of resource %s", r.Name)
+		}
+	}
+
 	r.Properties = resources.NewResource(r.Type, r.Action)
 	if r.Properties == nil {
 		return errors.Errorf("unknown resource type: %s", r.Type)
@@ -203,8 +283,20 @@ func (r *ResourceInTemplate) UnmarshalJSON(buf []byte) (err error) {
 
 // Parameter parameter in template
 type Parameter struct {
-	Type  string      `json:",omitempty"`
-	Value interface{} `json:",omitempty"`
+	Type        string      `json:",omitempty"`
+	Value       interface{} `json:",omitempty"`
+	Description string      `json:",omitempty"`
+	// NoEcho indicates value of parameter is sensitive and should be redacted
+	NoEcho bool `json:",omitempty"`
+
+	// constraints of value, items of list are checked separately except length
+	AllowedValues         []interface{} `json:",omitempty"`
+	MinValue              *int64        `json:",omitempty"`
+	MaxValue              *int64        `json:",omitempty"`
+	AllowedPattern        string        `json:",omitempty"`
+	MinLength             *int          `json:",omitempty"`
+	MaxLength             *int          `json:",omitempty"`
+	ConstraintDescription string        `json:",omitempty"`
 }
 
 // UnmarshalJSON interface
@@ -219,28 +311,11 @@ func (p *Parameter) UnmarshalJSON(buf []byte) (err error) {
 	}
 
 	p.Type = m["Type"].(string)
-	switch p.Type {
-	case "Integer":
-		var integer int64
-		err = json.Unmarshal(defaultBuf, &integer)
-		p.Value = integer
-	case "String":
-		var str string
-		err = json.Unmarshal(defaultBuf, &str)
-		p.Value = str
-	case "IntegerList":
-		integerList := []int64{}
-		err = json.Unmarshal(defaultBuf, &integerList)
-		p.Value = integerList
-	case "StringList":
-		strList := []string{}
-		err = json.Unmarshal(defaultBuf, &strList)
-		p.Value = strList
-
-	default:
-		err = errors.Errorf("unknown parameter type %s", p.Type)
+	// attributes are parsed at first so that value of NoEcho parameter isn't shown in errors
+	if err = p.unmarshalAttributes(buf); err != nil {
+		return errors.Trace(err)
 	}
-	if err != nil {
+	if err = p.setJSONValue(defaultBuf); err != nil {
 		return errors.Trace(err)
 	}
 
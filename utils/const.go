@@ -93,6 +93,7 @@ const (
 	GetAPIName     = "GetAPIName"
 	CreateAPIName  = "CreateAPIName"
 	UpdateAPIName  = "UpdateAPIName"
+	DeleteAPIName  = "DeleteAPIName"
 	RecordKey      = "RecordKey"
 	RecordsKey     = "RecordsKey"
 	StatusKey      = "StatusKey"
@@ -107,4 +108,5 @@ const (
 	ContextTypeString       = "String"
 	ContextTypeInteger      = "Integer"
 	ContextTypeBool         = "Bool"
+	ContextTypeBoolList     = "BoolList"
 )
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
+
 	openapi_client "xsky.com/sds-formation/openapi-client"
 )
 
@@ -18,20 +21,35 @@ type ResourceInterface interface {
 	Repr() (repr interface{})
 	GetType() (typeName string)
 	CheckInterval() (interval int)
+	ResolveProperties() (properties map[string]interface{}, err error)
+	References() (names []string)
 
 	IsReady() (ready bool)
-	Get() (err error)
-	Create() (created bool, err error)
-	IsCreated() (created bool, err error)
-	Update(repr interface{}) (updated bool, err error)
-	IsUpdated() (updated bool, err error)
-	Delete(repr interface{}) (deleted bool, err error)
-	IsDeleted() (deleted bool, err error)
+	Get(ctx context.Context) (err error)
+	Create(ctx context.Context) (created bool, err error)
+	IsCreated(ctx context.Context) (created bool, err error)
+	Update(ctx context.Context, repr interface{}) (updated bool, err error)
+	IsUpdated(ctx context.Context) (updated bool, err error)
+	Delete(ctx context.Context, repr interface{}) (deleted bool, err error)
+	IsDeleted(ctx context.Context) (deleted bool, err error)
+	PlannedRequests() (requests []*PlannedRequest)
+	Adopted() (adopted bool)
+	AdoptedItems() (identifies []string)
+}
+
+// PlannedRequest defines a request which would be sent to server if not in plan mode
+type PlannedRequest struct {
+	API        string
+	PathParams map[string]string `json:",omitempty"`
+	Body       json.RawMessage   `json:",omitempty"`
 }
 
 // StackInterface stack interface
 type StackInterface interface {
-	CallAPI(string, interface{}, map[string]string, ...map[string]string) ([]byte, error)
+	CallAPI(context.Context, string, interface{}, map[string]string,
+		...map[string]string) ([]byte, error)
 	GetOpenAPIClient() openapi_client.Client
 	GetResourceValue(string) interface{}
+	GetCondition(string) (bool, error)
+	FindInMap(string, string, string) (json.RawMessage, error)
 }
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedValue is shown in place of sensitive values
+const RedactedValue = "******"
+
+var secrets = struct {
+	sync.RWMutex
+	values   map[string]bool
+	replacer *strings.Replacer
+}{values: map[string]bool{}}
+
+// AddSecret registers a sensitive value which should be redacted by Redact
+func AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	secrets.Lock()
+	defer secrets.Unlock()
+	if secrets.values[secret] {
+		return
+	}
+	secrets.values[secret] = true
+
+	// value may be escaped in json, e.g. request body and cache record
+	var oldnew []string
+	for value := range secrets.values {
+		oldnew = append(oldnew, value)
+		if escaped, err := json.Marshal(value); err == nil {
+			if escapedValue := string(escaped[1 : len(escaped)-1]); escapedValue != value {
+				oldnew = append(oldnew, escapedValue)
+			}
+		}
+	}
+	// replace longer value at first in case a secret contains another one
+	sort.Slice(oldnew, func(i, j int) bool {
+		return len(oldnew[i]) > len(oldnew[j])
+	})
+	pairs := make([]string, 0, len(oldnew)*2)
+	for _, value := range oldnew {
+		pairs = append(pairs, value, RedactedValue)
+	}
+	secrets.replacer = strings.NewReplacer(pairs...)
+}
+
+// Redact replaces all registered sensitive values in text with RedactedValue
+func Redact(text string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+	if secrets.replacer == nil {
+		return text
+	}
+	return secrets.replacer.Replace(text)
+}
+
+type redactWriter struct {
+	writer io.Writer
+}
+
+// NewRedactWriter returns a writer which redacts sensitive values before writing to w
+func NewRedactWriter(w io.Writer) io.Writer {
+	return &redactWriter{writer: w}
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.writer, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type secretSuite struct {
+	suite.Suite
+}
+
+func (s *secretSuite) TestRedact() {
+	s.Equal("nothing to hide", Redact("nothing to hide"))
+
+	AddSecret("")
+	AddSecret("abc")
+	AddSecret("abcdef")
+	AddSecret(`p"w\d`)
+	// secret registered again is ignored
+	AddSecret("abc")
+
+	s.Equal("nothing to hide", Redact("nothing to hide"))
+	// longer secret is replaced at first
+	s.Equal("****** and ******", Redact("abcdef and abc"))
+	// secret escaped in json is replaced too
+	s.Equal(`{"password":"******"}`, Redact(`{"password":"p\"w\\d"}`))
+	s.Equal("password ******", Redact(`password p"w\d`))
+}
+
+func (s *secretSuite) TestRedactWriter() {
+	AddSecret("17412dde75c34e92ad7d931bb4b2c287")
+	var out bytes.Buffer
+	w := NewRedactWriter(&out)
+
+	data := []byte("token 17412dde75c34e92ad7d931bb4b2c287 is created\n")
+	n, err := w.Write(data)
+	s.NoError(err)
+	// length of data is returned though redacted text is written
+	s.Equal(len(data), n)
+	s.Equal("token ****** is created\n", out.String())
+}
+
+func TestSecretSuite(t *testing.T) {
+	suite.Run(t, new(secretSuite))
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"os"
+	"time"
 
 	"github.com/juju/errors"
 )
@@ -14,6 +15,18 @@ func NewContext() context.Context {
 	return context.Background()
 }
 
+// Sleep pauses for the duration, error is returned if the context is done before that
+func Sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	}
+}
+
 // GetHashString returns sha256 hash sum with hex encoding of bytes
 func GetHashString(bytes []byte) (string, error) {
 	h := sha1.New()
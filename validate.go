@@ -0,0 +1,404 @@
+package formation
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"xsky.com/sds-formation/config"
+	openapiClient "xsky.com/sds-formation/openapi-client"
+	"xsky.com/sds-formation/parser"
+	resources "xsky.com/sds-formation/resources"
+	"xsky.com/sds-formation/utils"
+)
+
+// validator checks a template statically without connecting to the cluster, problems found
+// are collected instead of returned one by one so that all of them could be fixed at once
+type validator struct {
+	template *Template
+	problems []string
+	found    map[string]bool
+	// names of templates being validated, in order of instantiation
+	path      []string
+	validated map[string]bool
+	// apis of resources are checked with the spec if it's set
+	spec openapiClient.Client
+}
+
+// scope is types of values of parameters, resources and template contexts which could be
+// referenced, type of value which can't be referenced as an expression is empty
+type scope map[string]string
+
+func (sc scope) copy() scope {
+	newScope := make(scope, len(sc))
+	for name, valueType := range sc {
+		newScope[name] = valueType
+	}
+	return newScope
+}
+
+// ValidateTemplate checks the template file, including unknown properties, references,
+// types of referenced values, resource names, templates and actions. Problems of the
+// template are returned, and error is returned if the template can't be parsed. Apis called
+// by resources are checked if openapi spec file is set.
+func ValidateTemplate(filePath string) ([]string, error) {
+	data, err := readTemplateFile(filePath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	template := new(Template)
+	if err = json.Unmarshal(data, template); err != nil {
+		return nil, errors.Annotatef(err, "parse template %s", filePath)
+	}
+	if err = template.loadImports(filepath.Dir(filePath)); err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = OverrideParameters(template.Parameters, config.ParamFile, config.Params)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	v := &validator{template: template, found: map[string]bool{}, validated: map[string]bool{}}
+	if config.OpenAPISpec != "" {
+		v.spec = openapiClient.NewOpenAPIClient(nil)
+		v.spec.SetSpecFile(config.OpenAPISpec)
+		if err = v.spec.LoadSpec(utils.NewContext()); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	v.checkFields("", data, reflect.TypeOf(template))
+	v.validate()
+	return v.problems, nil
+}
+
+func (v *validator) addProblem(format string, args ...interface{}) {
+	problem := fmt.Sprintf(format, args...)
+	// templates instantiated by multiple resources are validated multiple times
+	if !v.found[problem] {
+		v.found[problem] = true
+		v.problems = append(v.problems, problem)
+	}
+}
+
+func (v *validator) validate() {
+	t := v.template
+	if err := ValidateParameters(t.Parameters); err != nil {
+		v.addProblem("%s", err)
+	}
+	if _, ok := t.Parameters[utils.ParamClusterURL]; !ok {
+		v.addProblem("parameter %s is required", utils.ParamClusterURL)
+	}
+
+	// conditions are evaluated with parameters only
+	params := scope{}
+	for name, param := range t.Parameters {
+		if name != utils.ParamClusterURL {
+			params[name] = param.Type
+		}
+	}
+	for _, name := range sortedKeys(t.Conditions) {
+		v.checkReferences("condition "+name, t.Conditions[name], params)
+	}
+
+	topScope := v.validateResources("", t.Resources, params)
+	for _, name := range sortedKeys(t.Outputs) {
+		v.checkReferences("output "+name, t.Outputs[name].Value, topScope)
+	}
+
+	// templates not instantiated are validated without checking references, since their
+	// contexts are unknown
+	for _, name := range sortedKeys(t.Templates) {
+		if !v.validated[name] {
+			v.validateTemplate(name, nil)
+		}
+	}
+}
+
+// validateResources validates resources of the template or the top level, references are
+// not checked if scope is nil. Scope with names of resources is returned.
+func (v *validator) validateResources(templateName string, rs []*ResourceInTemplate,
+	sc scope) scope {
+
+	location := ""
+	if templateName != "" {
+		location = " in template " + templateName
+	}
+	if _, err := buildResourceGraph(rs); err != nil {
+		v.addProblem("%s%s", err, location)
+	}
+	if err := v.template.checkConditions(rs); err != nil {
+		v.addProblem("%s%s", err, location)
+	}
+
+	// values of resources are set once they are done, so a resource could only reference
+	// resources before it, or resources after it which are in its DependsOn
+	types := map[string]string{}
+	for _, r := range rs {
+		types[r.Name] = resources.ValueType(r.Type)
+	}
+	if sc != nil {
+		sc = sc.copy()
+	}
+	names := map[string]bool{}
+	for _, r := range rs {
+		desc := fmt.Sprintf("resource %s%s", r.Name, location)
+		var rScope scope
+		if sc != nil {
+			rScope = sc.copy()
+			for _, name := range r.DependsOn {
+				if valueType, ok := types[name]; ok {
+					rScope[name] = valueType
+				}
+			}
+			sc[r.Name] = types[r.Name]
+		}
+		// resource with the same name updates the former one
+		if names[r.Name] && r.Action != utils.ActionTypeUpdate {
+			v.addProblem("duplicate resource name %s%s", r.Name, location)
+		}
+		names[r.Name] = true
+
+		switch r.Action {
+		case "", utils.ActionTypeCreate, utils.ActionTypeGet, utils.ActionTypeUpdate:
+		default:
+			v.addProblem("invalid action %s of %s, %s, %s or %s is expected", r.Action, desc,
+				utils.ActionTypeCreate, utils.ActionTypeGet, utils.ActionTypeUpdate)
+		}
+
+		if r.Type == utils.ResourceTemplate {
+			v.validateTemplateResource(r, desc, rScope)
+			continue
+		}
+		r.Properties.Init(nil)
+		v.checkAPIs(r.Properties.GetType())
+		if sc != nil {
+			v.checkReferences(desc, r.Properties, rScope)
+		} else {
+			v.checkConditionReferences(desc, r.Properties)
+		}
+	}
+	return sc
+}
+
+// validateTemplateResource validates contexts of the template resource and resources of
+// the template instantiated by it
+func (v *validator) validateTemplateResource(r *ResourceInTemplate, desc string, sc scope) {
+	if _, ok := v.template.Templates[r.TemplateName]; !ok {
+		v.addProblem("template %s of %s not found", r.TemplateName, desc)
+		return
+	}
+	for i, name := range v.path {
+		if name == r.TemplateName {
+			v.addProblem("template instantiates itself: %s",
+				strings.Join(append(v.path[i:], name), " -> "))
+			return
+		}
+	}
+	if sc == nil {
+		v.validateTemplate(r.TemplateName, nil)
+		return
+	}
+
+	module := v.template.modules[r.TemplateName]
+	templateScope := sc.copy()
+	if module != nil {
+		for name, param := range module.Parameters {
+			templateScope[name] = param.Type
+		}
+	}
+	for _, context := range r.Context {
+		contextDesc := fmt.Sprintf("context %s of %s", context.Name, desc)
+		v.checkReferences(contextDesc, context.Value, sc)
+		contextType := context.Type
+		if context.Action == utils.ContextValueActionRange {
+			contextType = strings.TrimSuffix(contextType, "List")
+		}
+		if module != nil {
+			param, ok := module.Parameters[context.Name]
+			if !ok {
+				v.addProblem("parameter %s of module %s not found for %s",
+					context.Name, r.TemplateName, desc)
+			} else if param.Type != contextType {
+				v.addProblem("%s is %s, but parameter of module %s is %s",
+					contextDesc, contextType, r.TemplateName, param.Type)
+			}
+		}
+		templateScope[context.Name] = contextType
+	}
+	v.validateTemplate(r.TemplateName, templateScope)
+}
+
+// validateTemplate validates resources of the template, references are not checked if scope
+// is nil
+func (v *validator) validateTemplate(name string, sc scope) {
+	v.validated[name] = true
+	data := v.template.Templates[name]
+	v.checkFields("Templates."+name, data, reflect.TypeOf([]*ResourceInTemplate{}))
+	var rs []*ResourceInTemplate
+	if err := json.Unmarshal(data, &rs); err != nil {
+		v.addProblem("parse template %s: %s", name, err)
+		return
+	}
+
+	v.path = append(v.path, name)
+	defer func() {
+		v.path = v.path[:len(v.path)-1]
+	}()
+	sc = v.validateResources(name, rs, sc)
+	if module := v.template.modules[name]; module != nil && sc != nil {
+		for _, outputName := range sortedKeys(module.Outputs) {
+			desc := fmt.Sprintf("output %s of module %s", outputName, name)
+			v.checkReferences(desc, module.Outputs[outputName].Value, sc)
+		}
+	}
+}
+
+// checkAPIs checks apis called by resources of the type are in openapi spec
+func (v *validator) checkAPIs(typeName string) {
+	if v.spec == nil {
+		return
+	}
+	for _, api := range resources.APIs(typeName) {
+		if !v.spec.HasOperation(api) {
+			v.addProblem("api %s of resource type %s not found in openapi spec %s",
+				api, typeName, config.OpenAPISpec)
+		}
+	}
+}
+
+// checkReferences checks names referenced by the value are in scope, and types of values
+// referenced by Ref functions are expected
+func (v *validator) checkReferences(desc string, value interface{}, sc scope) {
+	var names []string
+	switch val := value.(type) {
+	case parser.ExprType:
+		if reflect.ValueOf(val).IsNil() {
+			return
+		}
+		names = val.References()
+	case utils.ResourceInterface:
+		names = val.References()
+	}
+	for _, name := range names {
+		if _, ok := sc[name]; !ok {
+			v.addProblem("%s references %s which is not found", desc, name)
+		}
+	}
+	v.checkConditionReferences(desc, value)
+	for _, ref := range parser.TypedReferences(value) {
+		valueType, ok := sc[ref.Name]
+		if ok && !isValueTypeCompatible(ref.ValueType, valueType) {
+			v.addProblem("%s references %s as %s, but its value is %s",
+				desc, ref.Name, ref.ValueType, valueType)
+		}
+	}
+}
+
+// checkConditionReferences checks conditions referenced by Condition and If functions in the
+// value are defined in Conditions
+func (v *validator) checkConditionReferences(desc string, value interface{}) {
+	for _, name := range parser.ConditionReferences(value) {
+		if _, ok := v.template.Conditions[name]; !ok {
+			v.addProblem("%s references condition %s which is not found", desc, name)
+		}
+	}
+}
+
+// isValueTypeCompatible returns whether value of the type could be used as expected type,
+// single value could be used as a list
+func isValueTypeCompatible(expected, valueType string) bool {
+	return expected == "" || valueType == "" || expected == valueType ||
+		expected == valueType+"List"
+}
+
+// checkFields checks keys of json objects in data are fields of the type, case of keys is
+// ignored as json.Unmarshal does. Expressions are not checked since they are parsed.
+func (v *validator) checkFields(path string, data json.RawMessage, typ reflect.Type) {
+	for typ.Kind() == reflect.Ptr {
+		if typ.Implements(exprType) {
+			return
+		}
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		fields := map[string]json.RawMessage{}
+		if json.Unmarshal(data, &fields) != nil {
+			return
+		}
+		for _, key := range sortedKeys(fields) {
+			field, ok := findField(typ, key)
+			if !ok {
+				v.addProblem("unknown property %s", strings.TrimPrefix(path+"."+key, "."))
+				continue
+			}
+			if field.Type == resourceType {
+				v.checkProperties(path+"."+key, fields, fields[key])
+				continue
+			}
+			v.checkFields(path+"."+key, fields[key], field.Type)
+		}
+	case reflect.Slice:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return
+		}
+		for i, item := range items {
+			v.checkFields(fmt.Sprintf("%s[%d]", path, i), item, typ.Elem())
+		}
+	case reflect.Map:
+		items := map[string]json.RawMessage{}
+		if json.Unmarshal(data, &items) != nil {
+			return
+		}
+		for _, key := range sortedKeys(items) {
+			v.checkFields(path+"."+key, items[key], typ.Elem())
+		}
+	}
+}
+
+// checkProperties checks properties of the resource with its type
+func (v *validator) checkProperties(path string, fields map[string]json.RawMessage,
+	data json.RawMessage) {
+
+	var typeName, action string
+	json.Unmarshal(fields["Type"], &typeName)
+	json.Unmarshal(fields["Action"], &action)
+	if typeName == utils.ResourceTemplate {
+		return
+	}
+	if properties := resources.NewResource(typeName, action); properties != nil {
+		v.checkFields(path, data, reflect.TypeOf(properties))
+	}
+}
+
+var (
+	exprType     = reflect.TypeOf((*parser.ExprType)(nil)).Elem()
+	resourceType = reflect.TypeOf((*utils.ResourceInterface)(nil)).Elem()
+)
+
+// findField finds public field of the struct by name, fields of embedded structs are ignored
+func findField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath == "" && !field.Anonymous && strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// sortedKeys returns keys of the map in order
+func sortedKeys(m interface{}) []string {
+	keys := make([]string, 0)
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
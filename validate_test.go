@@ -0,0 +1,226 @@
+package formation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"xsky.com/sds-formation/config"
+)
+
+type validateSuite struct {
+	suite.Suite
+
+	dir         string
+	oldOpenFile OpenFileFunc
+}
+
+func (s *validateSuite) SetupTest() {
+	s.oldOpenFile = OpenFile
+	OpenFile = realOpenFile
+
+	var err error
+	s.dir, err = ioutil.TempDir("", "formation-validate")
+	s.NoError(err)
+}
+
+func (s *validateSuite) TearDownTest() {
+	OpenFile = s.oldOpenFile
+	os.RemoveAll(s.dir)
+}
+
+func (s *validateSuite) validate(template string) ([]string, error) {
+	path := filepath.Join(s.dir, "template.json")
+	s.NoError(ioutil.WriteFile(path, []byte(template), 0644))
+	return ValidateTemplate(path)
+}
+
+func (s *validateSuite) TestValidateExamples() {
+	files, err := filepath.Glob("examples/*")
+	s.NoError(err)
+	for _, file := range files {
+		problems, err := ValidateTemplate(file)
+		s.NoError(err, file)
+		s.Empty(problems, file)
+	}
+}
+
+func (s *validateSuite) TestValidTemplate() {
+	problems, err := s.validate(`{
+		"Parameters": {
+			"ClusterURL": {"Type": "String", "Value": "http://10.0.0.1:8056/v1"},
+			"prefix": {"Type": "String", "Value": "vol"},
+			"sizes": {"Type": "IntegerList", "Value": [1, 2]}
+		},
+		"Resources": [{
+			"Name": "pool",
+			"Type": "Pool",
+			"Action": "Get",
+			"Properties": {"name": {"Sub": "${prefix}-pool"}}
+		}, {
+			"Name": "volumes",
+			"Type": "Template",
+			"TemplateName": "volume",
+			"Context": [{"Name": "size", "Type": "IntegerList", "Value": {"Ref": "sizes"}, "Action": "range"}]
+		}],
+		"Templates": {
+			"volume": [{
+				"Name": "volume",
+				"Type": "BlockVolume",
+				"Properties": {
+					"Name": {"Sub": "${prefix}-${size}"},
+					"PoolID": {"Ref": "pool"},
+					"Size": {"Ref": "size"}
+				}
+			}]
+		},
+		"Outputs": {
+			"Size": {"Type": "Integer", "Value": {"Length": {"Ref": "sizes"}}}
+		}
+	}`)
+	s.NoError(err)
+	s.Empty(problems)
+}
+
+func (s *validateSuite) TestInvalidTemplate() {
+	problems, err := s.validate(`{
+		"Parameters": {
+			"ClusterURL": {"Type": "String", "Value": "http://10.0.0.1:8056/v1"},
+			"name": {"Type": "String", "Value": "vol", "Defualt": "vol"}
+		},
+		"Resources": [{
+			"Name": "pool",
+			"Type": "Pool",
+			"Action": "Delete",
+			"Properties": {"Name": "pool", "Szie": 3}
+		}, {
+			"Name": "volume",
+			"Type": "BlockVolume",
+			"Properties": {"Name": {"Ref": "nmae"}, "PoolID": {"Ref": "name"}}
+		}, {
+			"Name": "pool",
+			"Type": "Pool",
+			"Properties": {"Name": "pool", "OsdIDs": {"Ref": "name"}}
+		}, {
+			"Name": "volumes",
+			"Type": "Template",
+			"TemplateName": "volumes"
+		}, {
+			"Name": "hosts",
+			"Type": "Template",
+			"TemplateName": "host"
+		}],
+		"Templates": {
+			"volumes": [{
+				"Name": "volume",
+				"Type": "BlockVolume",
+				"Properties": {"Name": {"Ref": "index"}, "Sizes": 1}
+			}, {
+				"Name": "volumes",
+				"Type": "Template",
+				"TemplateName": "volumes"
+			}]
+		}
+	}`)
+	s.NoError(err)
+	s.Equal([]string{
+		"unknown property Parameters.name.Defualt",
+		"unknown property Resources[0].Properties.Szie",
+		"invalid action Delete of resource pool, Create, Get or Update is expected",
+		"resource volume references nmae which is not found",
+		"resource volume references name as Integer, but its value is String",
+		"duplicate resource name pool",
+		"resource pool references name as IntegerList, but its value is String",
+		"unknown property Templates.volumes[0].Properties.Sizes",
+		"resource volume in template volumes references index which is not found",
+		"template instantiates itself: volumes -> volumes",
+		"template host of resource hosts not found",
+	}, problems)
+
+	_, err = s.validate(`{"Resources": [{"Name": "pool", "Type": "Pools"}]}`)
+	s.Error(err)
+}
+
+func (s *validateSuite) TestReferenceScope() {
+	problems, err := s.validate(`{
+		"Parameters": {"ClusterURL": {"Type": "String", "Value": "http://10.0.0.1:8056/v1"}},
+		"Resources": [{
+			"Name": "volume",
+			"Type": "BlockVolume",
+			"Properties": {"Name": "vol", "PoolID": {"Ref": "pool"}}
+		}, {
+			"Name": "snapshot_volume",
+			"Type": "BlockVolume",
+			"DependsOn": ["pool"],
+			"Properties": {"Name": "vol2", "PoolID": {"Ref": "pool"}}
+		}, {
+			"Name": "pool",
+			"Type": "Pool",
+			"Properties": {"Name": "pool"}
+		}, {
+			"Name": "volume2",
+			"Type": "BlockVolume",
+			"Properties": {"Name": "vol3", "PoolID": {"Ref": "pool"}}
+		}]
+	}`)
+	s.NoError(err)
+	s.Equal([]string{"resource volume references pool which is not found"}, problems)
+}
+
+func (s *validateSuite) TestUndefinedCondition() {
+	problems, err := s.validate(`{
+		"Parameters": {
+			"ClusterURL": {"Type": "String", "Value": "http://10.0.0.1:8056/v1"},
+			"disk_type": {"Type": "String", "Value": "SSD"}
+		},
+		"Conditions": {
+			"WithSSD": {"Equals": [{"Ref": "disk_type"}, "SSD"]},
+			"WithSSDAndHDD": {"And": [{"Condition": "WithSSD"}, {"Condition": "WithHDD"}]}
+		},
+		"Resources": [{
+			"Name": "pool",
+			"Type": "Pool",
+			"Properties": {"Name": {"If": ["WithSDD", "ssd-pool", "hdd-pool"]}}
+		}],
+		"Outputs": {
+			"PoolName": {"Type": "String", "Value": {"If": ["WithSSD", "ssd-pool", "hdd-pool"]}}
+		}
+	}`)
+	s.NoError(err)
+	s.Equal([]string{
+		"condition WithSSDAndHDD references condition WithHDD which is not found",
+		"resource pool references condition WithSDD which is not found",
+	}, problems)
+}
+
+func (s *validateSuite) TestOpenAPISpec() {
+	config.OpenAPISpec = filepath.Join(s.dir, "openapi.json")
+	defer func() {
+		config.OpenAPISpec = ""
+	}()
+	s.NoError(ioutil.WriteFile(config.OpenAPISpec, []byte(`{
+		"openapi": "3.0.0",
+		"info": {"version": "SDS_4.2.009.0"},
+		"paths": {
+			"/pools/": {"get": {"operationId": "ListPools"}, "post": {"operationId": "CreatePool"}},
+			"/pools/{pool_id}": {
+				"get": {"operationId": "GetPool", "parameters": [{"name": "pool_id", "in": "path"}]}
+			}
+		}
+	}`), 0644))
+
+	problems, err := s.validate(`{
+		"Parameters": {"ClusterURL": {"Type": "String", "Value": "http://10.0.0.1:8056/v1"}},
+		"Resources": [{"Name": "pool", "Type": "Pool", "Properties": {"Name": "pool"}}]
+	}`)
+	s.NoError(err)
+	s.Equal([]string{"api DeletePool of resource type Pool not found in openapi spec " +
+		config.OpenAPISpec}, problems)
+}
+
+func TestValidateSuite(t *testing.T) {
+	suite.Run(t, new(validateSuite))
+}
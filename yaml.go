@@ -0,0 +1,177 @@
+package formation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+	yaml "gopkg.in/yaml.v3"
+
+	"xsky.com/sds-formation/parser"
+)
+
+// legacyOctalRe matches integers with leading zeros, which are octal in YAML 1.1 only
+var legacyOctalRe = regexp.MustCompile(`^[-+]?0[0-9_]+$`)
+
+// isYAMLFile returns whether the template file is written in yaml by its extension
+func isYAMLFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// yamlToJSON converts yaml document to json, so that templates in yaml are parsed into the
+// same model as json templates. Comments are dropped and anchors are expanded. Scalars are
+// typed by YAML 1.2, so yes/no/on/off are strings, and integers with leading zeros like 0755
+// are kept as strings too, which are parsed as decimal by Integer parameters.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var value interface{}
+	if node.Kind != 0 {
+		markLegacyOctal(&node)
+		if err := node.Decode(&value); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	jsonData, err := json.Marshal(convertYAMLValue(value))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return jsonData, nil
+}
+
+// markLegacyOctal marks plain integers with leading zeros as strings, yaml.v3 still decodes
+// them as octal for compatibility with YAML 1.1
+func markLegacyOctal(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Style == 0 && node.ShortTag() == "!!int" &&
+		legacyOctalRe.MatchString(node.Value) {
+
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		markLegacyOctal(child)
+	}
+}
+
+// convertYAMLValue converts maps decoded by yaml, whose keys are interface{}, to maps with
+// string keys which could be encoded in json
+func convertYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = convertYAMLValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = convertYAMLValue(item)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			items = append(items, convertYAMLValue(item))
+		}
+		return items
+	}
+	return value
+}
+
+// readTemplateData converts data of template file to json if the file is yaml
+func readTemplateData(path string, data []byte) ([]byte, error) {
+	if !isYAMLFile(path) {
+		return data, nil
+	}
+	jsonData, err := yamlToJSON(data)
+	if err != nil {
+		return nil, errors.Annotatef(err, "parse yaml file %s", path)
+	}
+	return jsonData, nil
+}
+
+// resolveFilePaths joins relative paths of FromFile functions in the template data with
+// baseDir, so that files are found next to the template file instead of the working directory
+func resolveFilePaths(data []byte, baseDir string) ([]byte, error) {
+	if !bytes.Contains(data, []byte(`"`+parser.FuncNameFromFile+`"`)) {
+		return data, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		// invalid data is kept, so that the error is reported when the template is parsed
+		return data, nil
+	}
+	if !resolveFromFile(value, baseDir) {
+		return data, nil
+	}
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return jsonData, nil
+}
+
+// resolveFromFile replaces relative paths of FromFile functions in the value, it returns
+// whether any path is replaced
+func resolveFromFile(value interface{}, baseDir string) (resolved bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if path, ok := v[parser.FuncNameFromFile].(string); ok && len(v) == 1 {
+			if filepath.IsAbs(path) {
+				return false
+			}
+			v[parser.FuncNameFromFile] = filepath.Join(baseDir, path)
+			return true
+		}
+		for _, item := range v {
+			if resolveFromFile(item, baseDir) {
+				resolved = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if resolveFromFile(item, baseDir) {
+				resolved = true
+			}
+		}
+	}
+	return resolved
+}
+
+// readTemplateFile reads template or module file as json, relative paths of files in the
+// template are relative to directory of the file
+func readTemplateFile(path string) ([]byte, error) {
+	file, err := OpenFile(path, os.O_RDONLY)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, errors.Trace(err)
+	}
+	if err = file.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if data, err = readTemplateData(path, data); err != nil {
+		return nil, errors.Trace(err)
+	}
+	baseDir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if data, err = resolveFilePaths(data, baseDir); err != nil {
+		return nil, errors.Annotatef(err, "resolve file paths in %s", path)
+	}
+	return data, nil
+}
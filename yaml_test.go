@@ -0,0 +1,94 @@
+package formation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type yamlSuite struct {
+	suite.Suite
+}
+
+func (s *yamlSuite) TestYAMLToJSON() {
+	data, err := yamlToJSON([]byte(`
+# comment
+Parameters:
+  size: {Type: Integer, Value: 3}
+Resources:
+  - &pool
+    Name: pool1
+    Type: Pool
+    Properties:
+      Size: {Ref: size}
+      OsdIDs: [1, 2]
+  - <<: *pool
+    Name: pool2
+`))
+	s.NoError(err)
+	s.JSONEq(`{
+		"Parameters": {"size": {"Type": "Integer", "Value": 3}},
+		"Resources": [
+			{"Name": "pool1", "Type": "Pool", "Properties": {"Size": {"Ref": "size"}, "OsdIDs": [1, 2]}},
+			{"Name": "pool2", "Type": "Pool", "Properties": {"Size": {"Ref": "size"}, "OsdIDs": [1, 2]}}
+		]
+	}`, string(data))
+
+	_, err = yamlToJSON([]byte("Resources: [\n"))
+	s.Error(err)
+}
+
+func (s *yamlSuite) TestYAML12Scalars() {
+	data, err := yamlToJSON([]byte(`
+Parameters:
+  answer: {Type: String, Value: yes}
+  switch: {Type: String, Value: off}
+  mode: {Type: String, Value: 0755}
+  count: {Type: Integer, Value: 010}
+  enabled: {Type: Bool, Value: true}
+  quoted: {Type: String, Value: "0755"}
+`))
+	s.NoError(err)
+	s.JSONEq(`{"Parameters": {
+		"answer": {"Type": "String", "Value": "yes"},
+		"switch": {"Type": "String", "Value": "off"},
+		"mode": {"Type": "String", "Value": "0755"},
+		"count": {"Type": "Integer", "Value": "010"},
+		"enabled": {"Type": "Bool", "Value": true},
+		"quoted": {"Type": "String", "Value": "0755"}
+	}}`, string(data))
+
+	template := new(Template)
+	s.NoError(json.Unmarshal(data, template))
+	s.Equal("0755", template.Parameters["mode"].Value)
+	s.Equal(int64(10), template.Parameters["count"].Value)
+
+	data, err = yamlToJSON(nil)
+	s.NoError(err)
+	s.Equal("null", string(data))
+}
+
+func (s *yamlSuite) TestReadTemplateData() {
+	data, err := readTemplateData("template.json", []byte(`{"Description": "json"}`))
+	s.NoError(err)
+	s.Equal(`{"Description": "json"}`, string(data))
+
+	// examples in yaml and json are the same
+	yamlData, err := ioutil.ReadFile("examples/disk_list.yaml")
+	s.NoError(err)
+	yamlData, err = readTemplateData("examples/disk_list.yaml", yamlData)
+	s.NoError(err)
+	jsonData, err := ioutil.ReadFile("examples/disk_list.json")
+	s.NoError(err)
+	s.JSONEq(string(jsonData), string(yamlData))
+
+	template := new(Template)
+	s.NoError(json.Unmarshal(yamlData, template))
+	s.Len(template.Resources, 3)
+}
+
+func TestYAMLSuite(t *testing.T) {
+	suite.Run(t, new(yamlSuite))
+}